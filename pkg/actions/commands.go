@@ -16,11 +16,13 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/eclipse/codewind-installer/pkg/appconstants"
 	desktoputils "github.com/eclipse/codewind-installer/pkg/desktop_utils"
 	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/eclipse/codewind-installer/pkg/globals"
+	"github.com/eclipse/codewind-installer/pkg/project"
 	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -77,12 +79,39 @@ func Commands() {
 						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id of PFE which will be used to validate the project", Required: false},
 						cli.StringFlag{Name: "username", Usage: "Username for GitHub account authorized to download the provided GitHub repo", Required: false},
 						cli.StringFlag{Name: "password", Usage: "Password for GitHub account authorized to download the provided GitHub repo", Required: false},
+						cli.StringFlag{Name: "ref", Usage: "The branch, tag or commit of the GitHub repo to download (default: master)", Required: false},
+						cli.BoolFlag{Name: "offline", Usage: "Only use a template from the local template cache, without downloading it"},
+						cli.StringSliceFlag{Name: "param", Usage: "A value for a parameter the template declares, as key=value (can be repeated)"},
+						cli.BoolFlag{Name: "no-prompt", Usage: "Use the defaults of template parameters that were not given, rather than asking for them"},
 					},
 					Action: func(c *cli.Context) error {
 						ProjectCreate(c)
 						return nil
 					},
 				},
+				{
+					Name:  "new",
+					Usage: "Create a project from a template, validate it and bind it, removing it again if any step fails",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "url, u", Usage: "URL of the template to download", Required: true},
+						cli.StringFlag{Name: "path, p", Usage: "The path at which to create the new project", Required: true},
+						cli.StringFlag{Name: "name, n", Usage: "The name of the project (default: the last element of the path)", Required: false},
+						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id to bind the project to", Required: false},
+						cli.StringFlag{Name: "username", Usage: "Username for GitHub account authorized to download the provided GitHub repo", Required: false},
+						cli.StringFlag{Name: "password", Usage: "Password for GitHub account authorized to download the provided GitHub repo", Required: false},
+						cli.StringFlag{Name: "ref", Usage: "The branch, tag or commit of the GitHub repo to download (default: master)", Required: false},
+						cli.BoolFlag{Name: "offline", Usage: "Only use a template from the local template cache, without downloading it"},
+						cli.StringSliceFlag{Name: "param", Usage: "A value for a parameter the template declares, as key=value (can be repeated)"},
+						cli.BoolFlag{Name: "no-prompt", Usage: "Use the defaults of template parameters that were not given, rather than asking for them"},
+						cli.IntFlag{Name: "concurrency", Value: project.DefaultSyncConcurrency, Usage: "The number of files to upload in parallel", Required: false},
+						cli.BoolFlag{Name: "gitignore", Usage: "Also ignore the paths in the project's .gitignore"},
+						cli.BoolFlag{Name: "dockerignore", Usage: "Also ignore the paths in the project's .dockerignore"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectNew(c)
+						return nil
+					},
+				},
 				{
 					Name:    "validate",
 					Aliases: []string{""},
@@ -103,16 +132,75 @@ func Commands() {
 					Usage: "Bind a project to codewind for building and running",
 					Flags: []cli.Flag{
 						cli.StringFlag{Name: "name, n", Usage: "The name of the project", Required: true},
-						cli.StringFlag{Name: "language, l", Usage: "The project language", Required: true},
-						cli.StringFlag{Name: "type, t", Usage: "The type of the project", Required: true},
-						cli.StringFlag{Name: "path, p", Usage: "The path to the project", Required: true},
+						cli.StringFlag{Name: "language, l", Usage: "The project language. Required unless --git is given, when it is detected", Required: false},
+						cli.StringFlag{Name: "type, t", Usage: "The type of the project. Required unless --git is given, when it is detected", Required: false},
+						cli.StringFlag{Name: "path, p", Usage: "The path to the project. With --git, the empty directory to download the repository into", Required: true},
 						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id for the project", Required: false},
+						cli.StringFlag{Name: "git", Usage: "The URL of a GitHub repository to download into --path, validate and bind", Required: false},
+						cli.StringFlag{Name: "ref", Usage: "With --git, the branch, tag or commit to download (default: master)", Required: false},
+						cli.StringFlag{Name: "username", Usage: "With --git, the GitHub username", Required: false},
+						cli.StringFlag{Name: "password", Usage: "With --git, the GitHub password", Required: false},
+						cli.IntFlag{Name: "concurrency", Value: project.DefaultSyncConcurrency, Usage: "The number of files to upload in parallel", Required: false},
+						cli.BoolFlag{Name: "gitignore", Usage: "Also ignore the paths in the project's .gitignore"},
+						cli.BoolFlag{Name: "dockerignore", Usage: "Also ignore the paths in the project's .dockerignore"},
+						cli.BoolFlag{Name: "dry-run", Usage: "list the files that would be uploaded, without creating the project or uploading them"},
+						cli.BoolFlag{Name: "explain", Usage: "with --dry-run, also list the paths that would not be synced and the rule that excluded each one"},
 					},
 					Action: func(c *cli.Context) error {
 						ProjectBind(c)
 						return nil
 					},
 				},
+				{
+					Name:  "export",
+					Usage: "Write the Codewind configuration of a bound project, its settings and its links to a bundle that project import can bind on another connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "The project ID", Required: true},
+						cli.StringFlag{Name: "output, o", Usage: "The file to write the bundle to (default: <project name>.cw-bundle.json)", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectExport(c)
+						return nil
+					},
+				},
+				{
+					Name:  "import",
+					Usage: "Bind every project found in a workspace directory, or the project in a bundle made by project export, to a connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "workspace, ws", Usage: "The directory to search for projects", Required: false},
+						cli.StringFlag{Name: "bundle, b", Usage: "A bundle written by project export, to bind its project instead", Required: false},
+						cli.StringFlag{Name: "path, p", Usage: "With --bundle, the path to the project (default: the path in the bundle)", Required: false},
+						cli.StringFlag{Name: "name, n", Usage: "With --bundle, the name to bind the project with (default: the name in the bundle)", Required: false},
+						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id to bind the projects to", Required: false},
+						cli.IntFlag{Name: "depth", Value: project.DefaultImportDepth, Usage: "How many directories below the workspace to search for projects", Required: false},
+						cli.IntFlag{Name: "parallel", Value: 1, Usage: "The number of projects to bind in parallel", Required: false},
+						cli.BoolFlag{Name: "dry-run", Usage: "List the projects that would be bound and their language and type, without binding them"},
+						cli.IntFlag{Name: "concurrency", Value: project.DefaultSyncConcurrency, Usage: "The number of files of each project to upload in parallel", Required: false},
+						cli.BoolFlag{Name: "gitignore", Usage: "Also ignore the paths in each project's .gitignore"},
+						cli.BoolFlag{Name: "dockerignore", Usage: "Also ignore the paths in each project's .dockerignore"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectImport(c)
+						return nil
+					},
+				},
+				{
+					Name:  "move",
+					Usage: "Bind a project to another connection with the same language and type, re-create its links there and upload all of its files",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "The project ID", Required: true},
+						cli.StringFlag{Name: "to", Usage: "The connection id to move the project to", Required: true},
+						cli.StringFlag{Name: "path, p", Usage: "The path to the project (default: the path the project is bound with)", Required: false},
+						cli.BoolFlag{Name: "unbind", Usage: "Unbind the project from its current connection once it has moved"},
+						cli.IntFlag{Name: "concurrency", Value: project.DefaultSyncConcurrency, Usage: "The number of files to upload in parallel", Required: false},
+						cli.BoolFlag{Name: "gitignore", Usage: "Also ignore the paths in the project's .gitignore"},
+						cli.BoolFlag{Name: "dockerignore", Usage: "Also ignore the paths in the project's .dockerignore"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectMove(c)
+						return nil
+					},
+				},
 				{
 					Name:  "remove",
 					Usage: "Remove a project from codewind",
@@ -131,7 +219,16 @@ func Commands() {
 					Flags: []cli.Flag{
 						cli.StringFlag{Name: "path, p", Usage: "the path to the project", Required: true},
 						cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
-						cli.StringFlag{Name: "time, t", Usage: "UNIX timestamp of the last sync for the given project, in milliseconds", Required: true},
+						cli.StringFlag{Name: "time, t", Usage: "UNIX timestamp of the last sync for the given project, in milliseconds. Only used when there is no record of the last sync", Required: false},
+						cli.IntFlag{Name: "concurrency", Value: project.DefaultSyncConcurrency, Usage: "the number of files to upload in parallel", Required: false},
+						cli.BoolFlag{Name: "watch, w", Usage: "keep running and sync the project each time its files change"},
+						cli.StringFlag{Name: "max-file-size", Usage: "the largest modified file to upload without applying the max-file-size-policy, such as 50MB. Overrides maxFileSize in .cw-settings", Required: false},
+						cli.StringFlag{Name: "max-file-size-policy", Usage: "what to do with files larger than max-file-size: warn, skip or fail (default: warn). Overrides maxFileSizePolicy in .cw-settings", Required: false},
+						cli.StringFlag{Name: "rate-limit", Usage: "the most data to upload per second, such as 512KB. Overrides uploadRateLimit in .cw-settings", Required: false},
+						cli.BoolFlag{Name: "dry-run", Usage: "list the files that would be uploaded, without uploading them"},
+						cli.BoolFlag{Name: "explain", Usage: "with --dry-run, also list the paths that would not be synced and the rule that excluded each one"},
+						cli.BoolFlag{Name: "gitignore", Usage: "also ignore the paths in the project's .gitignore"},
+						cli.BoolFlag{Name: "dockerignore", Usage: "also ignore the paths in the project's .dockerignore"},
 					},
 					Action: func(c *cli.Context) error {
 						ProjectSync(c)
@@ -144,6 +241,13 @@ func Commands() {
 					Usage:   "List projects",
 					Flags: []cli.Flag{
 						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id of the remote deployment to use", Required: false},
+						cli.BoolFlag{Name: "all-connections", Usage: "List the projects of every connection, reporting connections that cannot be reached"},
+						cli.StringFlag{Name: "status", Usage: "Only list projects with this app status, or one of these comma separated statuses", Required: false},
+						cli.StringFlag{Name: "language, l", Usage: "Only list projects with this language, or one of these comma separated languages", Required: false},
+						cli.StringFlag{Name: "name, n", Usage: "Only list projects whose name matches this glob pattern, such as 'node*'", Required: false},
+						cli.StringFlag{Name: "sort", Usage: "The column to sort by: " + strings.Join(project.ListColumnNames, ", "), Required: false},
+						cli.BoolFlag{Name: "reverse", Usage: "Sort from last to first"},
+						cli.StringFlag{Name: "columns", Usage: "The comma separated columns to show: " + strings.Join(project.ListColumnNames, ", ") + " (default: " + strings.Join(project.DefaultListColumns, ",") + ")", Required: false},
 					},
 					Action: func(c *cli.Context) error {
 						ProjectList(c)
@@ -176,6 +280,113 @@ func Commands() {
 						return nil
 					},
 				},
+				{
+					Name:  "build",
+					Usage: "Build a single project, requires 'id'",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id,i", Usage: "Project ID", Required: true},
+						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id of the remote deployment to use", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectBuild(c)
+						return nil
+					},
+				},
+				{
+					Name:  "autobuild",
+					Usage: "Turn auto-build on or off for a single project, requires 'id' and either 'enable' or 'disable'",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id,i", Usage: "Project ID", Required: true},
+						cli.BoolFlag{Name: "enable", Usage: "Build the project each time its files change"},
+						cli.BoolFlag{Name: "disable", Usage: "Only build the project when a build is requested"},
+						cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id of the remote deployment to use", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectAutoBuild(c)
+						return nil
+					},
+				},
+				{
+					Name:  "logs",
+					Usage: "Print the build and app logs of a project, with the time of each line",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "The project ID", Required: true},
+						cli.StringFlag{Name: "conid", Usage: "The connection id of the project (default: the connection the project is bound to)"},
+						cli.BoolFlag{Name: "build", Usage: "Only print the build logs"},
+						cli.BoolFlag{Name: "app", Usage: "Only print the app logs"},
+						cli.BoolFlag{Name: "follow, f", Usage: "Keep running and print new lines as they are logged, including those of logs created later such as by a first build"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectLogs(c)
+						return nil
+					},
+				},
+				{
+					Name:  "wait",
+					Usage: "Wait until a project is running, built or stopped, printing each change in its status",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "The project ID", Required: true},
+						cli.StringFlag{Name: "conid", Usage: "The connection id of the project (default: the connection the project is bound to)"},
+						cli.StringFlag{Name: "for", Value: project.WaitForRunning, Usage: "The state to wait for: running, built or stopped"},
+						cli.DurationFlag{Name: "timeout", Value: project.DefaultWaitTimeout, Usage: "How long to wait, such as 90s or 5m"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectWait(c)
+						return nil
+					},
+				},
+				{
+					Name:  "settings",
+					Usage: "Validate, show and change a project's .cw-settings",
+					Subcommands: []cli.Command{
+						{
+							Name:  "validate",
+							Usage: "Validate .cw-settings against the schema, reporting the line of each problem",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "path, p", Usage: "The path to the project", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectSettingsValidate(c)
+								return nil
+							},
+						},
+						{
+							Name:      "show",
+							Usage:     "Show the settings, or the value of one field",
+							ArgsUsage: "[field]",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "path, p", Usage: "The path to the project", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectSettingsShow(c)
+								return nil
+							},
+						},
+						{
+							Name:      "set",
+							Usage:     "Set a field, giving arrays such as mavenProfiles as comma separated values",
+							ArgsUsage: "<field> <value>",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "path, p", Usage: "The path to the project", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectSettingsSet(c)
+								return nil
+							},
+						},
+						{
+							Name:  "migrate",
+							Usage: "Upgrade the settings to the current schema, renaming a legacy .mc-settings file",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "path, p", Usage: "The path to the project", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectSettingsMigrate(c)
+								return nil
+							},
+						},
+					},
+				},
 				{
 					Name:  "link",
 					Usage: "Manage project links",
@@ -230,6 +441,29 @@ func Commands() {
 								ProjectLinkDelete(c)
 								return nil
 							},
+						}, {
+							Name:  "graph",
+							Usage: "Shows the links between every project on a connection, flagging dangling links and cycles",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id of the remote deployment to use"},
+								cli.StringFlag{Name: "format, f", Value: project.LinkGraphFormatDOT, Usage: "The format to show the links in: dot, mermaid or json"},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectLinkGraph(c)
+								return nil
+							},
+						}, {
+							Name:  "apply",
+							Usage: "Creates, renames and removes links so the projects in a file have exactly the links it declares",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "file, f", Usage: "The YAML file declaring the links of each project, by project name", Required: true},
+								cli.StringFlag{Name: "conid", Value: "local", Usage: "The connection id of the remote deployment to use"},
+								cli.BoolFlag{Name: "dry-run", Usage: "Show the changes that would be made, without making them"},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectLinkApply(c)
+								return nil
+							},
 						},
 					},
 				},
@@ -428,6 +662,38 @@ func Commands() {
 						return nil
 					},
 				},
+				{
+					Name:  "cache",
+					Usage: "Save templates in the local template cache so projects can be created from them offline",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "url",
+							Usage: "URL of the template to cache (default: every enabled template)",
+						},
+						cli.StringFlag{
+							Name:  "ref",
+							Usage: "The branch, tag or commit of the template's GitHub repo to cache (default: master)",
+						},
+						cli.StringFlag{
+							Name:  "username",
+							Usage: "Username for GitHub account authorized to download the template",
+						},
+						cli.StringFlag{
+							Name:  "password",
+							Usage: "Password for GitHub account authorized to download the template",
+						},
+						cli.StringFlag{
+							Name:     "conid",
+							Value:    "local",
+							Usage:    "Connection ID",
+							Required: false,
+						},
+					},
+					Action: func(c *cli.Context) error {
+						CacheTemplates(c)
+						return nil
+					},
+				},
 				{
 					Name:  "repos",
 					Usage: "Manage template repos",
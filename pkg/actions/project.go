@@ -12,6 +12,7 @@
 package actions
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -51,7 +52,13 @@ func ProjectCreate(c *cli.Context) {
 		gitCredentials.Password = password
 	}
 
-	result, err := project.DownloadTemplate(destination, url, gitCredentials)
+	options, err := templateOptionsFromContext(c)
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+
+	result, err := project.DownloadTemplate(destination, url, gitCredentials, options)
 	if err != nil {
 		HandleProjectError(err)
 		os.Exit(1)
@@ -65,25 +72,218 @@ func ProjectCreate(c *cli.Context) {
 	ProjectValidate(c)
 }
 
+// ProjectNew : Downloads a template, validates it and binds it as one step, undoing all of them if any fails
+func ProjectNew(c *cli.Context) {
+	options, err := templateOptionsFromContext(c)
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+
+	response, err := project.NewProject(c, options)
+	printNewProjectResponse(response, err)
+}
+
+// printNewProjectResponse prints each step of creating and binding a project, exiting with an error if any failed
+func printNewProjectResponse(response *project.NewProjectResponse, err *project.ProjectError) {
+	if response == nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else {
+		for _, step := range response.Steps {
+			if step.Error != "" {
+				fmt.Printf("%v: %v (%v)\n", step.Name, step.Status, step.Error)
+			} else {
+				fmt.Printf("%v: %v\n", step.Name, step.Status)
+			}
+		}
+		if response.ProjectID != "" && err == nil {
+			fmt.Println("Project ID: " + response.ProjectID)
+		}
+	}
+	if err != nil {
+		if !printAsJSON {
+			HandleProjectError(err)
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// templateOptionsFromContext reads how to create a project from a template from the command's flags
+func templateOptionsFromContext(c *cli.Context) (project.TemplateOptions, *project.ProjectError) {
+	params, err := project.ParseTemplateParams(c.StringSlice("param"))
+	if err != nil {
+		return project.TemplateOptions{}, err
+	}
+	options := project.TemplateOptions{Ref: c.String("ref"), Offline: c.Bool("offline"), Params: params}
+	// only ask for missing parameters when someone is there to answer
+	if !printAsJSON && !c.Bool("no-prompt") && isTerminal(os.Stdin) {
+		options.Prompt = promptForTemplateParam
+	}
+	return options, nil
+}
+
+// stdinReader is shared by every prompt, so input buffered for one prompt is not lost to the next
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// promptForTemplateParam asks for the value of a template parameter on the terminal, a blank answer uses the default
+func promptForTemplateParam(param project.TemplateParameter) (string, error) {
+	question := param.Name
+	if param.Description != "" {
+		question += " (" + param.Description + ")"
+	}
+	if param.Default != "" {
+		question += " [" + param.Default + "]"
+	}
+	fmt.Print(question + ": ")
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// isTerminal returns true if the file is an interactive terminal rather than a pipe or file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // ProjectSync : Does a project Sync
 func ProjectSync(c *cli.Context) {
+	if c.Bool("dry-run") {
+		projectSyncDryRun(c)
+	}
+
+	if c.Bool("watch") {
+		err := project.WatchProject(c, printSyncResult)
+		if err != nil {
+			HandleProjectError(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	response, err := project.SyncProject(c)
 	if err != nil {
 		HandleProjectError(err)
 		os.Exit(1)
 	} else {
-		if printAsJSON {
-			jsonResponse, _ := json.Marshal(response)
-			fmt.Println(string(jsonResponse))
-		} else {
-			fmt.Println("Status: " + response.Status)
-		}
+		printSyncResult(response, nil)
+	}
+	exitIfUploadsFailed(response.UploadedFiles)
+	os.Exit(0)
+}
+
+// projectSyncDryRun prints what a sync would upload, and what it would leave out if asked to explain
+func projectSyncDryRun(c *cli.Context) {
+	response, err := project.SyncProjectDryRun(c)
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
 	}
+	printDryRunResult(response, c.Bool("explain"))
+	exitIfRefErrors(response)
 	os.Exit(0)
 }
 
+// printDryRunResult prints the files and directories a sync or bind would send, and the number of bytes it would upload
+func printDryRunResult(response *project.SyncDryRunResponse, explain bool) {
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+		return
+	}
+
+	fmt.Println("Directories:")
+	for _, directory := range response.DirectoryList {
+		fmt.Println("  " + directory)
+	}
+	fmt.Println("Files:")
+	for _, file := range response.FileList {
+		fmt.Println("  " + file)
+	}
+	fmt.Println("Files to upload:")
+	for _, file := range response.ModifiedList {
+		fmt.Println("  " + file)
+	}
+	fmt.Printf("Total bytes to upload: %d\n", response.TotalBytes)
+	for _, file := range response.SkippedFiles {
+		fmt.Printf("Skipped %v: %v\n", file.FilePath, file.Status)
+	}
+	for _, refError := range response.RefErrors {
+		fmt.Println("Error: " + refError)
+	}
+	if explain {
+		fmt.Println("Excluded:")
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, excluded := range response.ExcludedList {
+			fmt.Fprintln(w, "  "+excluded.Path+"\t"+excluded.Rule+"\t"+excluded.Source)
+		}
+		w.Flush()
+	}
+}
+
+// exitIfRefErrors exits with an error code once a dry run has been printed if any of its references could not be synced
+func exitIfRefErrors(response *project.SyncDryRunResponse) {
+	if len(response.RefErrors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printSyncResult prints the result of a sync, in watch mode each result is printed on its own line
+func printSyncResult(response *project.SyncResponse, err *project.ProjectError) {
+	if err != nil {
+		HandleProjectError(err)
+		return
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else {
+		fmt.Println("Status: " + response.Status)
+		for _, file := range response.UploadedFiles {
+			if file.Outcome == project.UploadOutcomeSkippedTooLarge {
+				logr.Warnf("Skipped %v", file.Status)
+			}
+		}
+		for _, file := range project.FailedUploads(response.UploadedFiles) {
+			logr.Errorf("Failed to upload %v: %v", file.FilePath, file.Status)
+		}
+	}
+}
+
+// exitIfUploadsFailed exits with an error code if any file could not be uploaded, so scripts can rely on the result
+func exitIfUploadsFailed(uploadedFiles []project.UploadedFile) {
+	if len(project.FailedUploads(uploadedFiles)) > 0 {
+		os.Exit(1)
+	}
+}
+
 // ProjectBind : Does a project bind
 func ProjectBind(c *cli.Context) {
+	if c.String("git") != "" {
+		response, err := project.BindFromGit(c)
+		printNewProjectResponse(response, err)
+	}
+
+	if c.Bool("dry-run") {
+		response, err := project.BindProjectDryRun(c)
+		if err != nil {
+			HandleProjectError(err)
+			os.Exit(1)
+		}
+		printDryRunResult(response, c.Bool("explain"))
+		exitIfRefErrors(response)
+		os.Exit(0)
+	}
+
 	response, err := project.BindProject(c)
 	if err != nil {
 		HandleProjectError(err)
@@ -95,8 +295,12 @@ func ProjectBind(c *cli.Context) {
 		} else {
 			fmt.Println("Project ID: " + response.ProjectID)
 			fmt.Println("Status: " + response.Status)
+			for _, file := range project.FailedUploads(response.UploadedFiles) {
+				logr.Errorf("Failed to upload %v: %v", file.FilePath, file.Status)
+			}
 		}
 	}
+	exitIfUploadsFailed(response.UploadedFiles)
 	os.Exit(0)
 }
 
@@ -122,45 +326,211 @@ func UpgradeProjects(c *cli.Context) {
 	os.Exit(0)
 }
 
-// ProjectList : Print the list of projects to the terminal
-func ProjectList(c *cli.Context) {
-	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+// ProjectExport : Writes the Codewind configuration of a project to a bundle file
+func ProjectExport(c *cli.Context) {
+	bundle, output, err := project.ExportBundle(c)
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(bundle)
+		fmt.Println(string(jsonResponse))
+	} else {
+		fmt.Printf("Exported %v with %v links to %v\n", bundle.Project.Name, len(bundle.Links), output)
+	}
+	os.Exit(0)
+}
 
-	conInfo, conInfoErr := connections.GetConnectionByID(conID)
-	if conInfoErr != nil {
-		HandleConnectionError(conInfoErr)
+// ProjectMove : Binds a project to another connection, re-creating its links there
+func ProjectMove(c *cli.Context) {
+	response, err := project.MoveProject(c)
+	if response == nil {
+		HandleProjectError(err)
 		os.Exit(1)
 	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else {
+		if response.ProjectID != "" {
+			fmt.Printf("Moved %v to connection %v\n", response.Name, response.TargetConnectionID)
+			fmt.Println("Project ID: " + response.ProjectID)
+		}
+		if response.Bind != nil {
+			for _, file := range project.FailedUploads(response.Bind.UploadedFiles) {
+				logr.Errorf("Failed to upload %v: %v", file.FilePath, file.Status)
+			}
+		}
+		printLinkResults(response.Links)
+		if response.SourceUnbound {
+			fmt.Printf("Unbound %v from connection %v\n", response.SourceProjectID, response.SourceConnectionID)
+		}
+	}
+	if err != nil {
+		if !printAsJSON {
+			HandleProjectError(err)
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// ProjectImport : Binds every project found in a workspace directory, or the project in a bundle
+func ProjectImport(c *cli.Context) {
+	if c.String("bundle") != "" {
+		projectImportBundle(c)
+	}
+	projectImportWorkspace(c)
+}
 
-	conURL, conErr := config.PFEOriginFromConnection(conInfo)
-	if conErr != nil {
-		HandleConfigError(conErr)
+// projectImportBundle binds the project in a bundle and re-creates its links
+func projectImportBundle(c *cli.Context) {
+	response, err := project.ImportBundle(c)
+	if response == nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else {
+		for _, file := range response.RestoredFiles {
+			fmt.Println("Restored " + file)
+		}
+		if response.ProjectID != "" {
+			fmt.Println("Project ID: " + response.ProjectID)
+		}
+		printLinkResults(response.Links)
+	}
+	if err != nil {
+		if !printAsJSON {
+			HandleProjectError(err)
+		}
 		os.Exit(1)
 	}
+	os.Exit(0)
+}
 
-	projects, getAllErr := project.GetAll(http.DefaultClient, conInfo, conURL)
-	if getAllErr != nil {
-		HandleProjectError(getAllErr)
+// printLinkResults prints whether each link of a project was re-created
+func printLinkResults(links []project.LinkResult) {
+	if len(links) == 0 {
+		return
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "ENVIRONMENT VARIABLE \tTARGET PROJECT \tSTATUS \tERROR")
+	for _, link := range links {
+		fmt.Fprintln(w, link.EnvName+"\t"+link.TargetProjectName+"\t"+link.Status+"\t"+link.Error)
+	}
+	fmt.Fprintln(w)
+	w.Flush()
+}
+
+// projectImportWorkspace binds every project found in a workspace directory
+func projectImportWorkspace(c *cli.Context) {
+	response, err := project.ImportWorkspace(c, printImportPlan)
+	if err != nil {
+		HandleProjectError(err)
 		os.Exit(1)
 	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else if !response.DryRun {
+		printImportedProjects(response.Projects, true)
+		fmt.Printf("Bound: %v, failed: %v, skipped: %v\n", response.Bound, response.Failed, response.Skipped)
+	}
+	if response.Failed > 0 {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
 
+// printImportPlan prints the projects found in a workspace, before they are bound
+func printImportPlan(plan *project.ImportWorkspaceResponse) {
 	if printAsJSON {
-		json, _ := json.Marshal(projects)
-		fmt.Println(string(json))
-	} else {
-		if len(projects) == 0 {
-			fmt.Println("No projects bound to Codewind")
+		return
+	}
+	if len(plan.Projects) == 0 {
+		fmt.Println("No projects found in " + plan.Workspace)
+		return
+	}
+	fmt.Printf("Projects found in %v to bind to connection %v:\n", plan.Workspace, plan.ConnectionID)
+	printImportedProjects(plan.Projects, false)
+}
+
+// printImportedProjects prints a table of the projects in a workspace import, with their project IDs once they are bound,
+// or the detector that recognised them before
+func printImportedProjects(projects []project.ImportedProject, bound bool) {
+	detailColumn := "DETECTED BY"
+	if bound {
+		detailColumn = "PROJECT ID"
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "NAME \tLANGUAGE \tTYPE \tSTATUS \t"+detailColumn+" \tLOCATION ON DISK")
+	for _, imported := range projects {
+		detail := imported.Detector
+		if bound {
+			detail = imported.ProjectID
+		}
+		fmt.Fprintln(w, imported.Name+"\t"+imported.Language+"\t"+imported.BuildType+"\t"+imported.Status+"\t"+detail+"\t"+imported.Path)
+	}
+	fmt.Fprintln(w)
+	w.Flush()
+	for _, imported := range projects {
+		if imported.Error != "" {
+			fmt.Printf("%v: %v\n", imported.Path, imported.Error)
+		}
+	}
+}
+
+// ProjectList : Print the list of projects to the terminal
+func ProjectList(c *cli.Context) {
+	response, err := project.ListProjects(c)
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+
+	if printAsJSON {
+		// a single connection is listed as it always has been, as an array of projects
+		var jsonResponse []byte
+		if c.Bool("all-connections") {
+			jsonResponse, _ = json.Marshal(response)
 		} else {
-			w := new(tabwriter.Writer)
-			w.Init(os.Stdout, 0, 8, 2, '\t', 0)
-			fmt.Fprintln(w, "PROJECT ID \tNAME \tLANGUAGE \tAPP STATUS \tLOCATION ON DISK")
-			for _, project := range projects {
-				appStatus := strings.Title(project.AppStatus)
-				fmt.Fprintln(w, project.ProjectID+"\t"+project.Name+"\t"+project.Language+"\t"+appStatus+"\t"+project.LocationOnDisk)
-			}
-			fmt.Fprintln(w)
-			w.Flush()
+			jsonResponse, _ = json.Marshal(response.Projects)
+		}
+		fmt.Println(string(jsonResponse))
+		os.Exit(0)
+	}
+
+	columns := project.DefaultListColumns
+	if c.String("columns") != "" {
+		columns = strings.Split(strings.ReplaceAll(strings.ToLower(c.String("columns")), " ", ""), ",")
+	} else if c.Bool("all-connections") {
+		columns = append([]string{"connection"}, columns...)
+	}
+	headers, rows, err := project.ProjectListTable(response.Projects, columns)
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if len(rows) == 0 {
+		fmt.Println("No projects bound to Codewind")
+	} else {
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, strings.Join(headers, " \t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
 		}
+		fmt.Fprintln(w)
+		w.Flush()
+	}
+	for _, unreachable := range response.Unreachable {
+		logr.Warnf("Unable to list the projects of connection %v: %v", unreachable.ConnectionID, unreachable.Error)
 	}
 	os.Exit(0)
 }
@@ -254,6 +624,100 @@ func ProjectRestart(c *cli.Context) {
 	os.Exit(0)
 }
 
+// ProjectBuild : builds a project
+func ProjectBuild(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+
+	conInfo, conURL, conErr := project.GetConnectionAndURL(conID)
+	if conErr != nil {
+		HandleProjectError(conErr)
+		os.Exit(1)
+	}
+	err := project.BuildProject(http.DefaultClient, conInfo, conURL, projectID)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: "Project build request accepted"})
+	fmt.Println(string(response))
+	os.Exit(0)
+}
+
+// ProjectAutoBuild : turns auto-build on or off for a project
+func ProjectAutoBuild(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	enable := c.Bool("enable")
+	if enable == c.Bool("disable") {
+		logr.Errorln("Must specify either --enable or --disable")
+		os.Exit(1)
+	}
+
+	conInfo, conURL, conErr := project.GetConnectionAndURL(conID)
+	if conErr != nil {
+		HandleProjectError(conErr)
+		os.Exit(1)
+	}
+	err := project.SetAutoBuild(http.DefaultClient, conInfo, conURL, projectID, enable)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	statusMessage := "Project auto-build disabled"
+	if enable {
+		statusMessage = "Project auto-build enabled"
+	}
+	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: statusMessage})
+	fmt.Println(string(response))
+	os.Exit(0)
+}
+
+// ProjectLogs : Prints the build and app logs of a project, one JSON object per line if --json is given
+func ProjectLogs(c *cli.Context) {
+	err := project.StreamProjectLogs(c, func(line project.LogLine) {
+		if printAsJSON {
+			jsonLine, _ := json.Marshal(line)
+			fmt.Println(string(jsonLine))
+		} else {
+			fmt.Printf("%v [%v/%v] %v\n", line.Time, line.Type, line.Log, line.Message)
+		}
+	})
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// ProjectWait : Waits for a project to reach a state, exiting with a non-zero code if its build fails or the timeout passes
+func ProjectWait(c *cli.Context) {
+	response, err := project.WaitForProject(c, func(transition project.WaitTransition) {
+		if !printAsJSON {
+			fmt.Printf("%v app: %v, build: %v\n", transition.Time, transition.AppStatus, transition.BuildStatus)
+		}
+	})
+	if response == nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else if response.Reached {
+		fmt.Printf("Project %v is %v\n", response.ProjectID, response.For)
+	}
+	if err != nil {
+		if !printAsJSON {
+			HandleProjectError(err)
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
 // ProjectLinkList : lists all the links for a project
 func ProjectLinkList(c *cli.Context) {
 	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
@@ -405,3 +869,166 @@ func ProjectLinkDelete(c *cli.Context) {
 	fmt.Println(string(response))
 	os.Exit(0)
 }
+
+// ProjectLinkGraph : prints the links between every project on a connection, warning about dangling links and cycles
+func ProjectLinkGraph(c *cli.Context) {
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	format := strings.TrimSpace(strings.ToLower(c.String("format")))
+	if printAsJSON {
+		format = project.LinkGraphFormatJSON
+	}
+
+	conInfo, conURL, conErr := project.GetConnectionAndURL(conID)
+	if conErr != nil {
+		HandleProjectError(conErr)
+		os.Exit(1)
+	}
+	graph, projectLinkErr := project.GetLinkGraph(http.DefaultClient, conInfo, conURL)
+	if projectLinkErr != nil {
+		HandleProjectError(projectLinkErr)
+		os.Exit(1)
+	}
+
+	if format == project.LinkGraphFormatJSON {
+		json, _ := json.Marshal(graph)
+		fmt.Println(string(json))
+		os.Exit(0)
+	}
+	diagram, formatErr := project.FormatLinkGraph(graph, format)
+	if formatErr != nil {
+		HandleProjectError(formatErr)
+		os.Exit(1)
+	}
+	fmt.Print(diagram)
+
+	// warnings go to stderr, so the diagram can be piped straight to a renderer
+	for _, link := range graph.Dangling {
+		logr.Warnf("Link %v from %v is dangling: %v is %v", link.EnvName, graph.ProjectName(link.From), graph.ProjectName(link.To), link.Reason)
+	}
+	for _, cycle := range graph.Cycles {
+		names := []string{}
+		for _, projectID := range cycle {
+			names = append(names, graph.ProjectName(projectID))
+		}
+		logr.Warnf("Links form a cycle: %v -> %v", strings.Join(names, " -> "), names[0])
+	}
+	os.Exit(0)
+}
+
+// ProjectLinkApply : creates, renames and removes links so projects have the links declared in a file
+func ProjectLinkApply(c *cli.Context) {
+	response, err := project.ApplyLinks(c)
+	if response == nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		json, _ := json.Marshal(response)
+		fmt.Println(string(json))
+	} else if len(response.Changes) == 0 {
+		fmt.Printf("Links are up to date (%v unchanged)\n", response.Unchanged)
+	} else {
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 2, '\t', 0)
+		fmt.Fprintln(w, "ACTION \tPROJECT \tENVIRONMENT VARIABLE \tTARGET \tSTATUS")
+		for _, change := range response.Changes {
+			envName := change.EnvName
+			if change.Action == project.LinkActionRename {
+				envName += " -> " + change.NewEnvName
+			}
+			fmt.Fprintln(w, change.Action+"\t"+change.ProjectName+"\t"+envName+"\t"+change.TargetName+"\t"+change.Status)
+		}
+		fmt.Fprintln(w)
+		w.Flush()
+		fmt.Printf("%v changes, %v unchanged\n", len(response.Changes), response.Unchanged)
+	}
+	if err != nil {
+		if !printAsJSON {
+			HandleProjectError(err)
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// ProjectSettingsValidate : Validates a project's .cw-settings against the schema
+func ProjectSettingsValidate(c *cli.Context) {
+	validation, err := project.ValidateSettings(strings.TrimSpace(c.String("path")))
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(validation)
+		fmt.Println(string(jsonResponse))
+	} else {
+		for _, problem := range validation.Problems {
+			fmt.Printf("%v:%v:%v: %v: %v: %v\n", validation.Path, problem.Line, problem.Column, problem.Severity, problem.Field, problem.Message)
+		}
+		if validation.Valid {
+			fmt.Printf("%v is valid for schema version %v\n", validation.Path, project.CWSettingsSchemaVersion)
+		}
+	}
+	if !validation.Valid {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// ProjectSettingsShow : Shows a project's .cw-settings, or a single field of it
+func ProjectSettingsShow(c *cli.Context) {
+	settings, err := project.ShowSettings(strings.TrimSpace(c.String("path")))
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if field := c.Args().First(); field != "" {
+		value, found := settings[field]
+		if !found {
+			logr.Errorf("%v is not set", field)
+			os.Exit(1)
+		}
+		jsonValue, _ := json.Marshal(value)
+		fmt.Println(string(jsonValue))
+		os.Exit(0)
+	}
+	utils.PrettyPrintJSON(settings)
+	os.Exit(0)
+}
+
+// ProjectSettingsSet : Sets a field of a project's .cw-settings
+func ProjectSettingsSet(c *cli.Context) {
+	if c.NArg() != 2 {
+		logr.Error("Expected a field and a value, such as: cwctl project settings set --path <path> internalPort 9080")
+		os.Exit(1)
+	}
+	err := project.SetSetting(strings.TrimSpace(c.String("path")), c.Args().Get(0), c.Args().Get(1))
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: "Set " + c.Args().Get(0)})
+	fmt.Println(string(response))
+	os.Exit(0)
+}
+
+// ProjectSettingsMigrate : Upgrades a project's settings to the current schema
+func ProjectSettingsMigrate(c *cli.Context) {
+	migration, err := project.MigrateSettings(strings.TrimSpace(c.String("path")))
+	if err != nil {
+		HandleProjectError(err)
+		os.Exit(1)
+	}
+	if printAsJSON {
+		jsonResponse, _ := json.Marshal(migration)
+		fmt.Println(string(jsonResponse))
+	} else if len(migration.Changes) == 0 {
+		fmt.Printf("%v is already schema version %v\n", migration.Path, migration.ToVersion)
+	} else {
+		fmt.Printf("Migrated %v from schema version %v to %v:\n", migration.Path, migration.FromVersion, migration.ToVersion)
+		for _, change := range migration.Changes {
+			fmt.Println("  " + change)
+		}
+	}
+	os.Exit(0)
+}
@@ -13,9 +13,11 @@ package actions
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/project"
 	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/urfave/cli"
 )
@@ -51,6 +53,41 @@ func ListTemplateStyles(c *cli.Context) {
 	utils.PrettyPrintJSON(styles)
 }
 
+// CacheTemplates saves templates in the local template cache, so projects can be created from them offline.
+// Without a URL, every enabled template of which Codewind is aware is cached.
+func CacheTemplates(c *cli.Context) {
+	url := c.String("url")
+	ref := c.String("ref")
+	username := c.String("username")
+	password := c.String("password")
+	var gitCredentials utils.GitCredentials
+	if username != "" && password != "" {
+		gitCredentials.Username = username
+		gitCredentials.Password = password
+	}
+
+	urls := []string{url}
+	if url == "" {
+		conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+		templates, err := apiroutes.GetTemplates(conID, "", true)
+		if err != nil {
+			templateErr := &TemplateError{errOpCacheTemplate, err, err.Error()}
+			HandleTemplateError(templateErr)
+			return
+		}
+		urls = []string{}
+		for _, template := range templates {
+			urls = append(urls, template.URL)
+		}
+	}
+
+	response := project.CacheTemplates(urls, ref, gitCredentials)
+	utils.PrettyPrintJSON(response)
+	if len(response.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
 // ListTemplateRepos lists all template repos of which Codewind is aware.
 func ListTemplateRepos(c *cli.Context) {
 	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
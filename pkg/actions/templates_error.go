@@ -28,6 +28,7 @@ const (
 	errOpDeleteRepo    = "DELETE_REPO_ERROR"
 	errOpEnableRepo    = "ENABLE_REPO_ERROR"
 	errOpDisableRepo   = "DISABLE_REPO_ERROR"
+	errOpCacheTemplate = "CACHE_TEMPLATE_ERROR"
 )
 
 // TemplateError : Error formatted in JSON containing an errorOp and a description
@@ -0,0 +1,72 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// CapabilityBatchUpload : PFE accepts a tar.gz of project files on /api/v1/projects/{id}/upload/batch
+const CapabilityBatchUpload = "batchUpload"
+
+// CapabilityRenameFiles : PFE moves the files in the renamedList sent to /api/v1/projects/{id}/upload/end,
+// so they do not need uploading again
+const CapabilityRenameFiles = "renameFiles"
+
+// GetPFECapabilities : Get the optional features advertised by the PFE environment API
+func GetPFECapabilities(connection *connections.Connection, conURL string, httpClient utils.HTTPClient) ([]string, error) {
+	req, err := http.NewRequest("GET", conURL+"/api/v1/environment", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, httpSecError := sechttp.DispatchHTTPRequest(httpClient, req, connection)
+	if httpSecError != nil {
+		return nil, httpSecError
+	}
+	defer resp.Body.Close()
+
+	// Older versions of PFE may not report anything, so treat a failed call as no capabilities
+	if resp.StatusCode != http.StatusOK {
+		return []string{}, nil
+	}
+
+	byteArray, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var env EnvResponse
+	err = json.Unmarshal(byteArray, &env)
+	if err != nil {
+		return nil, err
+	}
+	if env.Capabilities == nil {
+		return []string{}, nil
+	}
+	return env.Capabilities, nil
+}
+
+// HasCapability : Check whether a capability is in a list returned by GetPFECapabilities
+func HasCapability(capabilities []string, capability string) bool {
+	for _, advertised := range capabilities {
+		if advertised == capability {
+			return true
+		}
+	}
+	return false
+}
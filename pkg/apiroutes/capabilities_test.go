@@ -0,0 +1,62 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPFECapabilities(t *testing.T) {
+	mockConnection := connections.Connection{ID: "local"}
+
+	t.Run("Returns the advertised capabilities", func(t *testing.T) {
+		body := CreateMockResponseBody(EnvResponse{Version: "x.x.dev", Capabilities: []string{CapabilityBatchUpload}})
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		capabilities, err := GetPFECapabilities(&mockConnection, "dummyURL", mockClient)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{CapabilityBatchUpload}, capabilities)
+	})
+	t.Run("Returns no capabilities when PFE does not advertise any", func(t *testing.T) {
+		body := CreateMockResponseBody(EnvResponse{Version: "x.x.dev"})
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		capabilities, err := GetPFECapabilities(&mockConnection, "dummyURL", mockClient)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{}, capabilities)
+	})
+	t.Run("Returns no capabilities when the environment API is not found", func(t *testing.T) {
+		body := CreateMockResponseBody("")
+		mockClient := &MockResponse{StatusCode: http.StatusNotFound, Body: body}
+		capabilities, err := GetPFECapabilities(&mockConnection, "dummyURL", mockClient)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{}, capabilities)
+	})
+	t.Run("Returns an error when PFE cannot be contacted", func(t *testing.T) {
+		capabilities, err := GetPFECapabilities(&mockConnection, "dummyURL", &security.ClientMockRequestFail{})
+		assert.Error(t, err)
+		assert.Nil(t, capabilities)
+	})
+}
+
+func Test_HasCapability(t *testing.T) {
+	t.Run("Asserts capability in list", func(t *testing.T) {
+		assert.True(t, HasCapability([]string{"other", CapabilityRenameFiles}, CapabilityRenameFiles))
+	})
+	t.Run("Asserts capability not in list", func(t *testing.T) {
+		assert.False(t, HasCapability([]string{"other"}, CapabilityRenameFiles))
+		assert.False(t, HasCapability(nil, CapabilityRenameFiles))
+	})
+}
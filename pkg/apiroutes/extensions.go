@@ -32,13 +32,16 @@ func GetExtensions(conID string) ([]utils.Extension, error) {
 	if conErr != nil {
 		return nil, conErr.Err
 	}
+	return GetExtensionsFromConnection(conInfo, conURL, &http.Client{})
+}
 
+// GetExtensionsFromConnection : Get the project extensions from the PFE of a connection
+func GetExtensionsFromConnection(conInfo *connections.Connection, conURL string, httpClient utils.HTTPClient) ([]utils.Extension, error) {
 	req, err := http.NewRequest("GET", conURL+"/api/v1/extensions", nil)
 	if err != nil {
 		return nil, err
 	}
-	client := &http.Client{}
-	resp, httpSecError := sechttp.DispatchHTTPRequest(client, req, conInfo)
+	resp, httpSecError := sechttp.DispatchHTTPRequest(httpClient, req, conInfo)
 	if httpSecError != nil {
 		return nil, httpSecError
 	}
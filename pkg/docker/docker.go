@@ -15,6 +15,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -722,9 +723,14 @@ func InspectContainer(dockerClient DockerClient, containerID string) (types.Cont
 
 //GetContainerLogs : returns the container log for the specified container.
 func GetContainerLogs(dockerClient DockerClient, containerID string) (io.ReadCloser, *DockerError) {
+	return GetContainerLogsWithOptions(dockerClient, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+}
+
+// GetContainerLogsWithOptions : returns the log stream of a container, following it or adding timestamps as the options ask
+func GetContainerLogsWithOptions(dockerClient DockerClient, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, *DockerError) {
 	ctx := context.Background()
 
-	containerLogStream, err := dockerClient.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	containerLogStream, err := dockerClient.ContainerLogs(ctx, containerID, options)
 	if err != nil {
 		return nil, &DockerError{errOpContainerLogs, err, err.Error()}
 	}
@@ -732,6 +738,41 @@ func GetContainerLogs(dockerClient DockerClient, containerID string) (io.ReadClo
 	return containerLogStream, nil
 }
 
+// ScanContainerLogs : calls onLine with each line of the log stream of a container that was started without a TTY.
+// Docker sends stdout and stderr of such containers in frames, each with an 8 byte header giving the stream and the frame's length.
+func ScanContainerLogs(logStream io.Reader, onLine func(line string)) error {
+	header := make([]byte, 8)
+	partialLines := map[byte]string{}
+	for {
+		_, err := io.ReadFull(logStream, header)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		_, err = io.ReadFull(logStream, frame)
+		if err != nil {
+			return err
+		}
+
+		// a line may be split across frames, so keep what follows the last newline of each stream for its next frame
+		lines := strings.Split(partialLines[header[0]]+string(frame), "\n")
+		for _, line := range lines[:len(lines)-1] {
+			onLine(strings.TrimSuffix(line, "\r"))
+		}
+		partialLines[header[0]] = lines[len(lines)-1]
+	}
+	// stdin, stdout then stderr, so that the last lines are passed on in the same order each time
+	for stream := byte(0); stream <= 2; stream++ {
+		if line := partialLines[stream]; line != "" {
+			onLine(strings.TrimSuffix(line, "\r"))
+		}
+	}
+	return nil
+}
+
 //GetFilesFromContainer : returns the tar file stream for the path in the specified container.
 func GetFilesFromContainer(dockerClient DockerClient, containerID, path string) (io.ReadCloser, *DockerError) {
 	ctx := context.Background()
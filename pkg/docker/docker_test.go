@@ -12,6 +12,8 @@
 package docker
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
 
 	"github.com/docker/docker/api/types"
@@ -289,3 +291,30 @@ func TestGetCodewindProjectContainers(t *testing.T) {
 		})
 	}
 }
+
+func TestScanContainerLogs(t *testing.T) {
+	frame := func(stream byte, content string) []byte {
+		header := make([]byte, 8)
+		header[0] = stream
+		binary.BigEndian.PutUint32(header[4:], uint32(len(content)))
+		return append(header, content...)
+	}
+
+	t.Run("success case - lines split across frames and streams are kept whole", func(t *testing.T) {
+		logStream := bytes.Buffer{}
+		logStream.Write(frame(1, "Server starting\nListening on "))
+		logStream.Write(frame(2, "warning: deprecated\r\n"))
+		logStream.Write(frame(1, "port 3000\nno newline"))
+
+		lines := []string{}
+		err := ScanContainerLogs(&logStream, func(line string) { lines = append(lines, line) })
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Server starting", "warning: deprecated", "Listening on port 3000", "no newline"}, lines)
+	})
+
+	t.Run("fail case - a truncated frame", func(t *testing.T) {
+		truncated := frame(1, "Server starting\n")
+		err := ScanContainerLogs(bytes.NewReader(truncated[:12]), func(line string) {})
+		assert.NotNil(t, err)
+	})
+}
@@ -21,7 +21,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/eclipse/codewind-installer/pkg/config"
 	"github.com/eclipse/codewind-installer/pkg/connections"
 	"github.com/eclipse/codewind-installer/pkg/sechttp"
 	"github.com/eclipse/codewind-installer/pkg/utils"
@@ -67,15 +66,30 @@ func BindProject(c *cli.Context) (*BindResponse, *ProjectError) {
 	language := strings.TrimSpace(c.String("language"))
 	buildType := strings.TrimSpace(c.String("type"))
 	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
-	return Bind(projectPath, name, language, buildType, conID)
+	if language == "" || buildType == "" {
+		err := errors.New(textLanguageTypeRequired)
+		return nil, &ProjectError{errOpInvalidOptions, err, textLanguageTypeRequired}
+	}
+	options := syncOptionsFromContext(c)
+	return Bind(projectPath, name, language, buildType, conID, options)
 }
 
 // Bind is used to bind a project for building and running
-func Bind(projectPath string, name string, language string, projectType string, conID string) (*BindResponse, *ProjectError) {
+func Bind(projectPath string, name string, language string, projectType string, conID string, options SyncOptions) (*BindResponse, *ProjectError) {
 	_, err := os.Stat(projectPath)
 	if err != nil {
 		return nil, &ProjectError{errBadPath, err, err.Error()}
 	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	response, _, projErr := bind(&http.Client{}, conInfo, conURL, projectPath, name, language, projectType, options)
+	return response, projErr
+}
+
+// bind binds a project, also returning the ID PFE gave the project so a bind that fails after starting can be undone
+func bind(client utils.HTTPClient, conInfo *connections.Connection, conURL string, projectPath string, name string, language string, projectType string, options SyncOptions) (*BindResponse, string, *ProjectError) {
 	creationTime := time.Now().UnixNano() / 1000000
 
 	bindRequest := BindRequest{
@@ -86,37 +100,36 @@ func Bind(projectPath string, name string, language string, projectType string,
 		Time:        creationTime,
 	}
 
-	client := &http.Client{}
-
-	conInfo, conInfoErr := connections.GetConnectionByID(conID)
-	if conInfoErr != nil {
-		return nil, &ProjectError{errOpConNotFound, conInfoErr.Err, conInfoErr.Desc}
-	}
-
-	conURL, conURLErr := config.PFEOriginFromConnection(conInfo)
-	if conURLErr != nil {
-		return nil, &ProjectError{errOpConNotFound, conURLErr.Err, conURLErr.Desc}
-	}
-
 	projectInfo, projErr := bindToPFE(client, bindRequest, conInfo, conURL)
 
 	if projErr != nil {
-		return nil, projErr
+		return nil, "", projErr
 	}
 	projectID := projectInfo.ProjectID
 
 	// Sync all the project files
-	syncInfo, syncErr := syncFiles(&http.Client{}, projectPath, projectID, conURL, 0, conInfo)
+	syncInfo, syncErr := syncFiles(client, projectPath, projectID, conURL, 0, nil, options, conInfo)
+	if syncInfo == nil {
+		return nil, projectID, syncErr
+	}
 
 	// Call bind/end to complete
 	completeStatus, completeStatusCode := completeBind(client, projectID, conURL, conInfo)
+
+	// Record what was synced so the next sync only uploads what has changed
+	if completeStatusCode == http.StatusOK {
+		saveErr := saveSyncManifest(projectID, syncInfo.manifest)
+		if saveErr != nil {
+			logr.Warnf("Unable to save the sync manifest for project %v: %v", projectID, saveErr.Desc)
+		}
+	}
 	response := BindResponse{
 		ProjectID:     projectID,
 		UploadedFiles: syncInfo.UploadedFileList,
 		Status:        completeStatus,
 		StatusCode:    completeStatusCode,
 	}
-	return &response, syncErr
+	return &response, projectID, syncErr
 }
 
 func bindToPFE(client utils.HTTPClient, bindRequest BindRequest, conInfo *connections.Connection, conURL string) (*BindResponse, *ProjectError) {
@@ -171,11 +184,16 @@ func completeBind(client utils.HTTPClient, projectID string, conURL string, conn
 
 	// Make the request to end the sync process.
 	request, err := http.NewRequest("POST", bindEndURL, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		logr.Errorln(err)
+		return err.Error(), 0
+	}
 	request.Header.Set("Content-Type", "application/json")
 	resp, httpSecError := sechttp.DispatchHTTPRequest(client, request, connection)
 
 	if httpSecError != nil {
-		logr.Errorln(err)
+		logr.Errorln(httpSecError.Desc)
+		return httpSecError.Desc, 0
 	}
 	return resp.Status, resp.StatusCode
 }
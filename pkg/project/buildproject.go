@@ -0,0 +1,56 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+type (
+	// BuildParameters : The request structure to act on a project's builds
+	BuildParameters struct {
+		Action string `json:"action"`
+	}
+)
+
+// BuildProject calls the build API on the connected PFE to build the given projectID
+func BuildProject(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string) error {
+	return requestBuildAction(httpClient, conInfo, conURL, projectID, "build", http.StatusAccepted)
+}
+
+// SetAutoBuild calls the build API on the connected PFE to turn auto-build on or off for the given projectID
+func SetAutoBuild(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string, enable bool) error {
+	action := "disableautobuild"
+	if enable {
+		action = "enableautobuild"
+	}
+	return requestBuildAction(httpClient, conInfo, conURL, projectID, action, http.StatusOK)
+}
+
+func requestBuildAction(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string, action string, successCode int) error {
+	requestURL := conURL + "/api/v1/projects/" + projectID + "/build"
+	parameters := BuildParameters{
+		Action: action,
+	}
+	jsonPayload, _ := json.Marshal(parameters)
+	req, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return handleRestartResponse(req, conInfo, httpClient, successCode)
+}
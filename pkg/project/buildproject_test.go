@@ -0,0 +1,58 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuildProject(t *testing.T) {
+	t.Run("success case - returns nil error when PFE status code 202", func(t *testing.T) {
+		client := &clientMockPFE{routes: map[string]mockPFEResponse{"POST /api/v1/projects/mockID/build": {http.StatusAccepted, nil}}}
+		err := BuildProject(client, &mockConnection, "http://mockURL", "mockID")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{`POST /api/v1/projects/mockID/build {"action":"build"}`}, client.requests)
+	})
+
+	t.Run("error case - returns error when PFE status code non 202", func(t *testing.T) {
+		mockClient := &security.ClientMockAuthenticate{StatusCode: http.StatusBadRequest, Body: emptyResponseBody}
+		err := BuildProject(mockClient, &mockConnection, "mockURL", "mockID")
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_SetAutoBuild(t *testing.T) {
+	tests := map[string]struct {
+		enable     bool
+		wantAction string
+	}{
+		"enable":  {enable: true, wantAction: "enableautobuild"},
+		"disable": {enable: false, wantAction: "disableautobuild"},
+	}
+	for name, test := range tests {
+		t.Run("success case - "+name, func(t *testing.T) {
+			client := &clientMockPFE{routes: map[string]mockPFEResponse{"POST /api/v1/projects/mockID/build": {http.StatusOK, nil}}}
+			err := SetAutoBuild(client, &mockConnection, "http://mockURL", "mockID", test.enable)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{`POST /api/v1/projects/mockID/build {"action":"` + test.wantAction + `"}`}, client.requests)
+		})
+	}
+
+	t.Run("error case - returns error when the project is not found", func(t *testing.T) {
+		err := SetAutoBuild(&clientMockPFE{}, &mockConnection, "http://mockURL", "mockID", true)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,311 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// ProjectBundleVersion is the version of the bundle format written by this CLI
+const ProjectBundleVersion = 1
+
+// The status of re-creating each link of an imported or moved project
+const (
+	LinkStatusCreated = "created"
+	LinkStatusSkipped = "skipped"
+	LinkStatusFailed  = "failed"
+)
+
+type (
+	// ProjectBundle : The Codewind configuration of a bound project, which can be imported on another connection
+	ProjectBundle struct {
+		BundleVersion int              `json:"bundleVersion"`
+		ExportedAt    int64            `json:"exportedAt"`
+		Project       BundleProject    `json:"project"`
+		Connection    BundleConnection `json:"connection"`
+		CWSettings    json.RawMessage  `json:"cwSettings,omitempty"`
+		RefPaths      json.RawMessage  `json:"cwRefPaths,omitempty"`
+		Links         []BundleLink     `json:"links"`
+	}
+
+	// BundleProject : The project a bundle was exported from
+	BundleProject struct {
+		ProjectID   string `json:"projectID"`
+		Name        string `json:"name"`
+		Language    string `json:"language"`
+		ProjectType string `json:"projectType"`
+		Path        string `json:"projectPath"`
+	}
+
+	// BundleConnection : The connection a bundle was exported from
+	BundleConnection struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+		URL   string `json:"url"`
+	}
+
+	// BundleLink : A link from the exported project, with the name of its target so it can be found on another connection
+	BundleLink struct {
+		EnvName           string `json:"envName"`
+		TargetProjectID   string `json:"targetProjectID"`
+		TargetProjectName string `json:"targetProjectName"`
+	}
+
+	// LinkResult : The outcome of re-creating one link
+	LinkResult struct {
+		BundleLink
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// BundleImportResponse : The result of importing a bundle
+	BundleImportResponse struct {
+		ProjectID     string        `json:"projectID"`
+		Name          string        `json:"name"`
+		Path          string        `json:"projectPath"`
+		ConnectionID  string        `json:"connectionID"`
+		RestoredFiles []string      `json:"restoredFiles"`
+		Bind          *BindResponse `json:"bind,omitempty"`
+		Links         []LinkResult  `json:"links"`
+	}
+)
+
+// ExportBundle : Export the Codewind configuration of the project given by --id to the file given by --output
+func ExportBundle(c *cli.Context) (*ProjectBundle, string, *ProjectError) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return nil, "", projErr
+	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, "", projErr
+	}
+
+	bundle, projErr := ExportProject(http.DefaultClient, conInfo, conURL, projectID)
+	if projErr != nil {
+		return nil, "", projErr
+	}
+	output := strings.TrimSpace(c.String("output"))
+	if output == "" {
+		output = bundle.Project.Name + ".cw-bundle.json"
+	}
+	return bundle, output, WriteProjectBundle(bundle, output)
+}
+
+// ExportProject : Read the Codewind configuration of a bound project into a bundle
+func ExportProject(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string) (*ProjectBundle, *ProjectError) {
+	project, projErr := GetProjectFromID(httpClient, conInfo, conURL, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	links, projErr := GetProjectLinks(httpClient, conInfo, conURL, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+
+	bundle := &ProjectBundle{
+		BundleVersion: ProjectBundleVersion,
+		ExportedAt:    time.Now().UnixNano() / 1000000,
+		Project: BundleProject{
+			ProjectID:   project.ProjectID,
+			Name:        project.Name,
+			Language:    project.Language,
+			ProjectType: project.ProjectType,
+			Path:        project.LocationOnDisk,
+		},
+		Connection: BundleConnection{ID: conInfo.ID, Label: conInfo.Label, URL: conURL},
+		Links:      []BundleLink{},
+	}
+
+	if len(links) > 0 {
+		// links only hold the ID of their target, which will be different on another connection
+		projects, projErr := GetAll(httpClient, conInfo, conURL)
+		if projErr != nil {
+			return nil, projErr
+		}
+		names := map[string]string{}
+		for _, target := range projects {
+			names[target.ProjectID] = target.Name
+		}
+		for _, link := range links {
+			bundle.Links = append(bundle.Links, BundleLink{EnvName: link.EnvName, TargetProjectID: link.ProjectID, TargetProjectName: names[link.ProjectID]})
+		}
+	}
+
+	// the settings files are only available when the project is on this machine
+	bundle.CWSettings, projErr = readBundledFile(project.LocationOnDisk, cwSettingsFile)
+	if projErr != nil {
+		return nil, projErr
+	}
+	bundle.RefPaths, projErr = readBundledFile(project.LocationOnDisk, ignoreSourceRefPaths)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return bundle, nil
+}
+
+// readBundledFile reads a JSON file from a project, returning nothing if the project or file is not on this machine
+func readBundledFile(projectPath string, filename string) (json.RawMessage, *ProjectError) {
+	if projectPath == "" {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(filepath.Join(projectPath, filename))
+	if err != nil {
+		return nil, nil
+	}
+	if !json.Valid(content) {
+		err := fmt.Errorf("%v in %v is not valid JSON", filename, projectPath)
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	return json.RawMessage(content), nil
+}
+
+// WriteProjectBundle : Write a bundle to a file
+func WriteProjectBundle(bundle *ProjectBundle, path string) *ProjectError {
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	err = ioutil.WriteFile(path, append(content, '\n'), 0644)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+// ReadProjectBundle : Read a bundle from a file, checking this CLI understands it
+func ReadProjectBundle(path string) (*ProjectBundle, *ProjectError) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+	var bundle ProjectBundle
+	err = json.Unmarshal(content, &bundle)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	if bundle.BundleVersion < 1 || bundle.BundleVersion > ProjectBundleVersion {
+		err := fmt.Errorf("bundle version %v is not supported, this CLI reads version %v", bundle.BundleVersion, ProjectBundleVersion)
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	if bundle.Project.Name == "" || bundle.Project.Language == "" || bundle.Project.ProjectType == "" {
+		err := errors.New("bundle does not give the project's name, language and type")
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	return &bundle, nil
+}
+
+// ImportBundle : Bind the project in the bundle given by --bundle to the connection given by --conid, restoring its
+// settings files and re-creating its links to projects that are bound to that connection
+func ImportBundle(c *cli.Context) (*BundleImportResponse, *ProjectError) {
+	if c.String("workspace") != "" {
+		err := errors.New(textImportSource)
+		return nil, &ProjectError{errOpInvalidOptions, err, textImportSource}
+	}
+	bundle, projErr := ReadProjectBundle(strings.TrimSpace(c.String("bundle")))
+	if projErr != nil {
+		return nil, projErr
+	}
+	projectPath := firstNonEmpty(strings.TrimSpace(c.String("path")), bundle.Project.Path)
+	name := firstNonEmpty(strings.TrimSpace(c.String("name")), bundle.Project.Name)
+	projErr = checkProjectPathExists(projectPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return importProjectBundle(http.DefaultClient, conInfo, conURL, bundle, projectPath, name, syncOptionsFromContext(c))
+}
+
+// importProjectBundle writes a bundle's settings files to the project, binds it and re-creates its links
+func importProjectBundle(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, bundle *ProjectBundle, projectPath string, name string, options SyncOptions) (*BundleImportResponse, *ProjectError) {
+	response := &BundleImportResponse{Name: name, Path: projectPath, ConnectionID: conInfo.ID, RestoredFiles: []string{}, Links: []LinkResult{}}
+	bundledFiles := []struct {
+		filename string
+		content  json.RawMessage
+	}{{cwSettingsFile, bundle.CWSettings}, {ignoreSourceRefPaths, bundle.RefPaths}}
+	for _, file := range bundledFiles {
+		filename, content := file.filename, file.content
+		if len(content) == 0 {
+			continue
+		}
+		// the bundle was written indented, so indent the file again from its own start
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, content, "", "  "); err != nil {
+			return nil, &ProjectError{errOpFileParse, err, err.Error()}
+		}
+		indented.WriteString("\n")
+		if err := ioutil.WriteFile(filepath.Join(projectPath, filename), indented.Bytes(), 0644); err != nil {
+			return nil, &ProjectError{errOpFileWrite, err, err.Error()}
+		}
+		response.RestoredFiles = append(response.RestoredFiles, filename)
+	}
+
+	bindResponse, projectID, projErr := bind(httpClient, conInfo, conURL, projectPath, name, bundle.Project.Language, bundle.Project.ProjectType, options)
+	response.Bind = bindResponse
+	response.ProjectID = projectID
+	if projErr == nil {
+		projErr = checkBindCompleted(bindResponse)
+	}
+	if projErr != nil {
+		return response, projErr
+	}
+
+	response.Links = recreateLinks(httpClient, conInfo, conURL, projectID, bundle.Links)
+	return response, nil
+}
+
+// recreateLinks creates each link on a connection, finding its target by name, and skipping it if no project with that name is bound
+func recreateLinks(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string, links []BundleLink) []LinkResult {
+	results := []LinkResult{}
+	if len(links) == 0 {
+		return results
+	}
+	projects, projErr := GetAll(httpClient, conInfo, conURL)
+	targetIDs := map[string]string{}
+	for _, target := range projects {
+		targetIDs[target.Name] = target.ProjectID
+	}
+
+	for _, link := range links {
+		result := LinkResult{BundleLink: link, Status: LinkStatusCreated}
+		targetID, found := targetIDs[link.TargetProjectName]
+		switch {
+		case projErr != nil:
+			result.Status, result.Error = LinkStatusFailed, projErr.Desc
+		case !found:
+			result.Status, result.Error = LinkStatusSkipped, fmt.Sprintf("no project named %v is bound to connection %v", link.TargetProjectName, conInfo.ID)
+		default:
+			if linkErr := CreateProjectLink(httpClient, conInfo, conURL, projectID, targetID, link.EnvName); linkErr != nil {
+				result.Status, result.Error = LinkStatusFailed, linkErr.Desc
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,159 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPFEResponse is the canned response to one route of clientMockPFE, whose body is sent as JSON unless it is a string
+type mockPFEResponse struct {
+	statusCode int
+	body       interface{}
+}
+
+// clientMockPFE answers requests by their method and path, or by their method, host and path when
+// requests to different connections need different answers, recording each request it was sent
+type clientMockPFE struct {
+	mutex    sync.Mutex
+	routes   map[string]mockPFEResponse
+	requests []string
+}
+
+func (c *clientMockPFE) Do(req *http.Request) (*http.Response, error) {
+	route := req.Method + " " + req.URL.Path
+	request := route
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		if len(body) > 0 {
+			request += " " + string(body)
+		}
+	}
+	c.mutex.Lock()
+	c.requests = append(c.requests, request)
+	response, found := c.routes[req.Method+" "+req.URL.Host+req.URL.Path]
+	if !found {
+		response, found = c.routes[route]
+	}
+	c.mutex.Unlock()
+
+	if !found {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(bytes.NewReader([]byte{}))}, nil
+	}
+	body, isString := response.body.(string)
+	if !isString {
+		marshalled, _ := json.Marshal(response.body)
+		body = string(marshalled)
+	}
+	return &http.Response{StatusCode: response.statusCode, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+}
+
+func TestExportProject(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "bundle_test")
+	defer os.RemoveAll(projectPath)
+	ioutil.WriteFile(filepath.Join(projectPath, cwSettingsFile), []byte(`{"schemaVersion": 1, "internalPort": "3000"}`), 0644)
+	mockConnection := connections.Connection{ID: "local", Label: "Local"}
+
+	t.Run("success case - the project, its settings and its links are exported", func(t *testing.T) {
+		client := &clientMockPFE{routes: map[string]mockPFEResponse{
+			"GET /api/v1/projects/frontend-id/":      {http.StatusOK, Project{ProjectID: "frontend-id", Name: "frontend", Language: "javascript", ProjectType: "nodejs", LocationOnDisk: projectPath}},
+			"GET /api/v1/projects/frontend-id/links": {http.StatusOK, []Link{{ProjectID: "backend-id", EnvName: "BACKEND_URL"}}},
+			"GET /api/v1/projects/":                  {http.StatusOK, []Project{{ProjectID: "frontend-id", Name: "frontend"}, {ProjectID: "backend-id", Name: "backend"}}},
+		}}
+
+		bundle, err := ExportProject(client, &mockConnection, "http://pfe", "frontend-id")
+		assert.Nil(t, err)
+		assert.Equal(t, BundleProject{ProjectID: "frontend-id", Name: "frontend", Language: "javascript", ProjectType: "nodejs", Path: projectPath}, bundle.Project)
+		assert.Equal(t, BundleConnection{ID: "local", Label: "Local", URL: "http://pfe"}, bundle.Connection)
+		assert.Equal(t, []BundleLink{{EnvName: "BACKEND_URL", TargetProjectID: "backend-id", TargetProjectName: "backend"}}, bundle.Links)
+		assert.JSONEq(t, `{"schemaVersion": 1, "internalPort": "3000"}`, string(bundle.CWSettings))
+		assert.Nil(t, bundle.RefPaths)
+
+		bundlePath := filepath.Join(projectPath, "frontend.cw-bundle.json")
+		assert.Nil(t, WriteProjectBundle(bundle, bundlePath))
+		read, err := ReadProjectBundle(bundlePath)
+		assert.Nil(t, err)
+		assert.Equal(t, bundle.Links, read.Links)
+		assert.Equal(t, bundle.Project, read.Project)
+	})
+
+	t.Run("fail case - the project is not found", func(t *testing.T) {
+		_, err := ExportProject(&clientMockPFE{}, &mockConnection, "http://pfe", "missing-id")
+		assert.Equal(t, errOpNotFound, err.Op)
+	})
+}
+
+func TestReadProjectBundle(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "bundle_test")
+	defer os.RemoveAll(testDir)
+
+	tests := map[string]string{
+		"a newer version":     `{"bundleVersion": 2, "project": {"name": "a", "language": "go", "projectType": "docker"}}`,
+		"no version":          `{"project": {"name": "a", "language": "go", "projectType": "docker"}}`,
+		"no project type":     `{"bundleVersion": 1, "project": {"name": "a", "language": "go"}}`,
+		"not a bundle at all": `[1, 2, 3]`,
+	}
+	for name, content := range tests {
+		t.Run("fail case - "+name, func(t *testing.T) {
+			bundlePath := filepath.Join(testDir, "bundle.json")
+			ioutil.WriteFile(bundlePath, []byte(content), 0644)
+			_, err := ReadProjectBundle(bundlePath)
+			assert.Equal(t, errOpFileParse, err.Op)
+		})
+	}
+}
+
+func TestImportProjectBundle(t *testing.T) {
+	defer RemoveSyncManifest("new-id")
+	projectPath, _ := ioutil.TempDir("", "bundle_test")
+	defer os.RemoveAll(projectPath)
+	bundle := &ProjectBundle{
+		BundleVersion: ProjectBundleVersion,
+		Project:       BundleProject{Name: "frontend", Language: "javascript", ProjectType: "nodejs"},
+		CWSettings:    json.RawMessage("{\n    \"internalPort\": \"3000\"\n  }"),
+		Links: []BundleLink{
+			{EnvName: "BACKEND_URL", TargetProjectID: "old-backend-id", TargetProjectName: "backend"},
+			{EnvName: "DB_URL", TargetProjectID: "old-db-id", TargetProjectName: "db"},
+		},
+	}
+	client := &clientMockPFE{routes: map[string]mockPFEResponse{
+		"GET /api/v1/projects/":                 {http.StatusOK, []Project{{ProjectID: "new-backend-id", Name: "backend"}}},
+		"POST /api/v1/projects/bind/start":      {http.StatusAccepted, BindResponse{ProjectID: "new-id"}},
+		"PUT /api/v1/projects/new-id/upload":    {http.StatusOK, nil},
+		"POST /api/v1/projects/new-id/bind/end": {http.StatusOK, nil},
+		"POST /api/v1/projects/new-id/links":    {http.StatusAccepted, nil},
+	}}
+
+	response, err := importProjectBundle(client, &mockConnection, "http://remote-pfe", bundle, projectPath, "frontend", SyncOptions{})
+	assert.Nil(t, err)
+	assert.Regexp(t, `^POST /api/v1/projects/bind/start {"language":"javascript","projectType":"nodejs","name":"frontend"`, client.requests[0])
+	assert.Equal(t, "new-id", response.ProjectID)
+	assert.Equal(t, []string{cwSettingsFile}, response.RestoredFiles)
+	content, _ := ioutil.ReadFile(filepath.Join(projectPath, cwSettingsFile))
+	assert.Equal(t, "{\n  \"internalPort\": \"3000\"\n}\n", string(content))
+
+	assert.Equal(t, []LinkResult{
+		{BundleLink: bundle.Links[0], Status: LinkStatusCreated},
+		{BundleLink: bundle.Links[1], Status: LinkStatusSkipped, Error: "no project named db is bound to connection local"},
+	}, response.Links)
+	assert.Contains(t, client.requests, `POST /api/v1/projects/new-id/links {"envName":"BACKEND_URL","targetProjectID":"new-backend-id"}`)
+}
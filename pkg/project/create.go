@@ -19,11 +19,9 @@ import (
 	"net/http"
 	"os"
 	"path"
-	"path/filepath"
 	"regexp"
 	"strings"
 
-	"github.com/eclipse/codewind-installer/pkg/config"
 	"github.com/eclipse/codewind-installer/pkg/connections"
 
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
@@ -32,15 +30,25 @@ import (
 )
 
 type (
+	// TemplateOptions : How a project is created from a template
+	TemplateOptions struct {
+		Ref     string              // the branch, tag or commit of a repo template, defaults to master
+		Offline bool                // only use a template that is already in the local template cache
+		Params  map[string]string   // values for the parameters the template declares
+		Prompt  TemplateParamPrompt // asks for parameters that were not given, nil to use their defaults
+	}
+
 	// ValidationResponse represents the response to validating a project on the users filesystem.
 	ValidationResponse struct {
-		Status string      `json:"status"`
-		Path   string      `json:"projectPath"`
-		Result interface{} `json:"result"`
+		Status     string               `json:"status"`
+		Path       string               `json:"projectPath"`
+		Result     interface{}          `json:"result"`
+		Candidates []DetectionCandidate `json:"candidates"`
 	}
 
 	// CWSettings represents the .cw-settings file which is written to a project
 	CWSettings struct {
+		SchemaVersion     int      `json:"schemaVersion,omitempty"`
 		ContextRoot       string   `json:"contextRoot"`
 		InternalPort      string   `json:"internalPort"`
 		HealthCheck       string   `json:"healthCheck"`
@@ -50,11 +58,18 @@ type (
 		MavenProfiles     []string `json:"mavenProfiles,omitempty"`
 		MavenProperties   []string `json:"mavenProperties,omitempty"`
 		StatusPingTimeout string   `json:"statusPingTimeout"`
+		MaxFileSize       string   `json:"maxFileSize,omitempty"`
+		MaxFileSizePolicy string   `json:"maxFileSizePolicy,omitempty"`
+		UploadRateLimit   string   `json:"uploadRateLimit,omitempty"`
 	}
 )
 
-// DownloadTemplate using the url/link provided
-func DownloadTemplate(destination, url string, gitCredentials utils.GitCredentials) (*Result, *ProjectError) {
+// DownloadTemplate using the url/link provided, through the local template cache, filling in the parameters the template declares
+func DownloadTemplate(destination, url string, gitCredentials utils.GitCredentials, options TemplateOptions) (*Result, *ProjectError) {
+	return downloadTemplate(getTemplateCacheDir(), destination, url, gitCredentials, options)
+}
+
+func downloadTemplate(cacheDir, destination, url string, gitCredentials utils.GitCredentials, options TemplateOptions) (*Result, *ProjectError) {
 	projErr := checkProjectDirIsEmpty(destination)
 	if projErr != nil {
 		return nil, projErr
@@ -70,12 +85,35 @@ func DownloadTemplate(destination, url string, gitCredentials utils.GitCredentia
 		projectName = "PROJ_NAME_PLACEHOLDER"
 	}
 
-	err := utils.DownloadFromURLThenExtract(url, destination, gitCredentials)
+	entry, projErr := fetchTemplate(cacheDir, url, options.Ref, gitCredentials, options.Offline)
+	if projErr != nil {
+		return nil, &ProjectError{errOpCreateProject, projErr.Err, projErr.Desc}
+	}
+
+	// the parameters are resolved before anything is written, so a bad value does not leave a half created project
+	manifest, projErr := readTemplateManifest(entry.Path)
+	if projErr != nil {
+		return nil, &ProjectError{errOpCreateProject, projErr.Err, projErr.Desc}
+	}
+	params, projErr := resolveTemplateParams(manifest, options.Params, options.Prompt)
+	if projErr != nil {
+		return nil, &ProjectError{errOpCreateProject, projErr.Err, projErr.Desc}
+	}
+
+	err := copyDirectory(entry.Path, destination)
+	if err != nil {
+		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+	err = os.RemoveAll(path.Join(destination, templateManifestFile))
 	if err != nil {
 		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
 	}
 
-	err = utils.ReplaceInFiles(destination, "[PROJ_NAME_PLACEHOLDER]", projectName)
+	replacements := map[string]string{"[PROJ_NAME_PLACEHOLDER]": projectName}
+	for name, value := range params {
+		replacements[templatePlaceholder(name)] = value
+	}
+	err = utils.ReplaceAllInFiles(destination, replacements)
 	if err != nil {
 		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
 	}
@@ -85,13 +123,7 @@ func DownloadTemplate(destination, url string, gitCredentials utils.GitCredentia
 }
 
 // checkIsExtension checks if a project is an extension project and run associated commands as necessary
-func checkIsExtension(conID, projectPath string, c *cli.Context) (string, error) {
-	extensions, err := apiroutes.GetExtensions(conID)
-	if err != nil {
-		log.Println("There was a problem retrieving extensions data")
-		return "unknown", err
-	}
-
+func checkIsExtension(extensions []utils.Extension, projectPath string, c *cli.Context) (string, error) {
 	params := make(map[string]string)
 	commandName := "postProjectValidate"
 
@@ -114,26 +146,41 @@ func checkIsExtension(conID, projectPath string, c *cli.Context) (string, error)
 			isMatch = extension.ProjectType == params["$type"]
 		} else {
 			// check if project contains the detection file an extension defines
-			isMatch = extension.Detection != "" && utils.PathExists(path.Join(projectPath, extension.Detection))
+			isMatch = hasExtensionDetectionFile(extension, projectPath)
 		}
 
 		if isMatch {
-			var cmdErr error
-			// check if there are any commands to run
-			for _, command := range extension.Commands {
-				if command.Name == commandName {
-					cmdErr = utils.RunCommand(projectPath, command, params)
-					break
-				}
-			}
-
-			return extension.ProjectType, cmdErr
+			return extension.ProjectType, runExtensionCommand(extension, projectPath, commandName, params)
 		}
 	}
 
 	return "", nil
 }
 
+// detectedExtension returns the first extension whose detection file the project contains, or nil if there is none
+func detectedExtension(extensions []utils.Extension, projectPath string) *utils.Extension {
+	for i := range extensions {
+		if hasExtensionDetectionFile(extensions[i], projectPath) {
+			return &extensions[i]
+		}
+	}
+	return nil
+}
+
+func hasExtensionDetectionFile(extension utils.Extension, projectPath string) bool {
+	return extension.Detection != "" && utils.PathExists(path.Join(projectPath, extension.Detection))
+}
+
+// runExtensionCommand runs the extension's command with the given name in the project, if it has one
+func runExtensionCommand(extension utils.Extension, projectPath string, commandName string, params map[string]string) error {
+	for _, command := range extension.Commands {
+		if command.Name == commandName {
+			return utils.RunCommand(projectPath, command, params)
+		}
+	}
+	return nil
+}
+
 // ValidateProject returns the language and buildType for a project at given filesystem path,
 // and writes a default .cw-settings file to that project
 func ValidateProject(c *cli.Context) (*ValidationResponse, *ProjectError) {
@@ -143,17 +190,39 @@ func ValidateProject(c *cli.Context) (*ValidationResponse, *ProjectError) {
 	if projErr != nil {
 		return nil, projErr
 	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return validateProject(&http.Client{}, conInfo, conURL, c)
+}
 
+func validateProject(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, c *cli.Context) (*ValidationResponse, *ProjectError) {
+	projectPath := c.String("path")
 	validationStatus := "success"
+	extensions, err := apiroutes.GetExtensionsFromConnection(conInfo, conURL, httpClient)
+	if err != nil {
+		log.Println("There was a problem retrieving extensions data")
+	}
+
+	// rank what the project could be, with the most likely first
+	candidates := detectProject(projectPath, extensionDetectors(extensions))
+	language, buildType := "unknown", "docker"
+	if len(candidates) > 0 {
+		language, buildType = candidates[0].Language, candidates[0].BuildType
+	}
+
 	// result could be ProjectType or string, so define as an interface
 	var validationResult interface{}
-	language, buildType := determineProjectInfo(projectPath)
 	validationResult = ProjectType{
 		Language:  language,
 		BuildType: buildType,
 	}
 
-	extensionType, err := checkIsExtension(conID, projectPath, c)
+	extensionType := "unknown"
+	if err == nil {
+		extensionType, err = checkIsExtension(extensions, projectPath, c)
+	}
 	if extensionType != "" {
 		if err == nil {
 			validationResult = ProjectType{
@@ -167,16 +236,17 @@ func ValidateProject(c *cli.Context) (*ValidationResponse, *ProjectError) {
 	}
 
 	response := ValidationResponse{
-		Status: validationStatus,
-		Path:   projectPath,
-		Result: validationResult,
+		Status:     validationStatus,
+		Path:       projectPath,
+		Result:     validationResult,
+		Candidates: candidates,
 	}
 
 	if err != nil {
 		return nil, &ProjectError{errOpCreateProject, err, err.Error()}
 	}
 
-	writeErr := writeCwSettingsIfNotInProject(conID, projectPath, buildType)
+	writeErr := writeCwSettingsIfNotInProject(httpClient, conInfo, conURL, projectPath, buildType)
 	if writeErr != nil {
 		return nil, writeErr
 	}
@@ -184,27 +254,17 @@ func ValidateProject(c *cli.Context) (*ValidationResponse, *ProjectError) {
 	return &response, nil
 }
 
-func writeCwSettingsIfNotInProject(conID string, projectPath string, BuildType string) *ProjectError {
-	pathToCwSettings := path.Join(projectPath, ".cw-settings")
-	pathToLegacySettings := path.Join(projectPath, ".mc-settings")
-
-	connection, conErr := connections.GetConnectionByID(conID)
-	if conErr != nil {
-		return &ProjectError{errOpCreateProject, conErr, conErr.Desc}
-	}
+func writeCwSettingsIfNotInProject(httpClient utils.HTTPClient, connection *connections.Connection, conURL string, projectPath string, BuildType string) *ProjectError {
+	pathToCwSettings := path.Join(projectPath, cwSettingsFile)
+	pathToLegacySettings := path.Join(projectPath, legacySettingsFile)
 
-	conURL, configErr := config.PFEOriginFromConnection(connection)
-	if configErr != nil {
-		return &ProjectError{errOpCreateProject, conErr, configErr.Desc}
-	}
-
-	if _, err := os.Stat(pathToLegacySettings); os.IsExist(err) {
+	if utils.PathExists(pathToLegacySettings) && !utils.PathExists(pathToCwSettings) {
 		projErr := renameLegacySettings(pathToLegacySettings, pathToCwSettings)
 		if projErr != nil {
 			return projErr
 		}
 	} else if _, err := os.Stat(pathToCwSettings); os.IsNotExist(err) {
-		projErr := writeNewCwSettings(&http.Client{}, connection, conURL, pathToCwSettings, BuildType)
+		projErr := writeNewCwSettings(httpClient, connection, conURL, pathToCwSettings, BuildType)
 		if projErr != nil {
 			return projErr
 		}
@@ -212,11 +272,16 @@ func writeCwSettingsIfNotInProject(conID string, projectPath string, BuildType s
 	return nil
 }
 
+// The errors checkProjectDirIsEmpty returns when it refuses a directory, so callers know nothing has been written to it
+var (
+	errNoProjectPath       = errors.New(textNoProjectPath)
+	errProjectPathNonEmpty = errors.New(textProjectPathNonEmpty)
+)
+
 // checkProjectDirIsEmpty return a project error if the given local filepath already exists, or is an empty string
 func checkProjectDirIsEmpty(projectPath string) *ProjectError {
 	if projectPath == "" {
-		err := errors.New(textNoProjectPath)
-		return &ProjectError{errOpCreateProject, err, err.Error()}
+		return &ProjectError{errOpCreateProject, errNoProjectPath, textNoProjectPath}
 	}
 
 	// if the project dir already exists, continue if empty and exit if not
@@ -226,8 +291,7 @@ func checkProjectDirIsEmpty(projectPath string) *ProjectError {
 			return &ProjectError{errOpCreateProject, err, err.Error()}
 		}
 		if !dirIsEmpty {
-			projErr := errors.New(textProjectPathNonEmpty)
-			return &ProjectError{errOpCreateProject, projErr, projErr.Error()}
+			return &ProjectError{errOpCreateProject, errProjectPathNonEmpty, textProjectPathNonEmpty}
 		}
 	}
 	return nil
@@ -246,67 +310,13 @@ func checkProjectPathExists(projectPath string) *ProjectError {
 	return nil
 }
 
-// determineProjectInfo returns the language and build-type of a project
+// determineProjectInfo returns the language and build-type the detectors are most confident a project has
 func determineProjectInfo(projectPath string) (string, string) {
-	language, buildType := "unknown", "docker"
-	if utils.PathExists(path.Join(projectPath, "pom.xml")) {
-		language = "java"
-		buildType = determineJavaBuildType(projectPath)
-	} else if utils.PathExists(path.Join(projectPath, "package.json")) {
-		language = "javascript"
-		buildType = "nodejs"
-	} else if utils.PathExists(path.Join(projectPath, "Package.swift")) {
-		language = "swift"
-		buildType = "swift"
-	} else {
-		determinedLanguage, err := determineProjectLanguage(projectPath)
-		if err != nil {
-			language = "unknown"
-		}
-		language = determinedLanguage
-		buildType = "docker"
-	}
-	return language, buildType
-}
-
-func determineJavaBuildType(projectPath string) string {
-	pathToPomXML := path.Join(projectPath, "pom.xml")
-	pomXMLContents, err := ioutil.ReadFile(pathToPomXML)
-	// if there is an error reading the pom.xml, we build as docker
-	if err != nil {
-		return "docker"
-	}
-	pomXMLString := string(pomXMLContents)
-	if strings.Contains(pomXMLString, "<groupId>org.springframework.boot</groupId>") {
-		return "spring"
-	}
-	pathToDockerfile := path.Join(projectPath, "Dockerfile")
-	dockerfileContents, err := ioutil.ReadFile(pathToDockerfile)
-	dockerfileString := string(dockerfileContents)
-	if strings.Contains(dockerfileString, "FROM websphere-liberty") {
-		return "liberty"
-	}
-	return "docker"
-}
-
-func determineProjectLanguage(projectPath string) (string, *ProjectError) {
-	projectFiles, err := ioutil.ReadDir(projectPath)
-	if err != nil {
-		return "", &ProjectError{errOpCreateProject, err, err.Error()}
-	}
-	for _, file := range projectFiles {
-		if !file.IsDir() {
-			switch filepath.Ext(file.Name()) {
-			case ".py":
-				return "python", nil
-			case ".go":
-				return "go", nil
-			default:
-				continue
-			}
-		}
+	candidates := detectProject(projectPath, nil)
+	if len(candidates) == 0 {
+		return "unknown", "docker"
 	}
-	return "unknown", nil
+	return candidates[0].Language, candidates[0].BuildType
 }
 
 // RenameLegacySettings renames a .mc-settings file to .cw-settings
@@ -328,6 +338,7 @@ func writeNewCwSettings(httpClient utils.HTTPClient, connection *connections.Con
 	}
 
 	cwSettings := addNonDefaultFieldsToCwSettings(defaultCwSettings, BuildType)
+	cwSettings.SchemaVersion = CWSettingsSchemaVersion
 	settings, err := json.MarshalIndent(cwSettings, "", "  ")
 	if err != nil {
 		return &ProjectError{errOpCreateProject, err, err.Error()}
@@ -41,7 +41,7 @@ func TestDownloadTemplate(t *testing.T) {
 		url := test.PublicGHRepoURL
 		gitCredentials := utils.GitCredentials{}
 
-		out, err := DownloadTemplate(dest, url, gitCredentials)
+		out, err := DownloadTemplate(dest, url, gitCredentials, TemplateOptions{})
 
 		assert.Equal(t, "success", out.Status)
 		assert.Nil(t, err)
@@ -61,7 +61,7 @@ func TestDownloadTemplate(t *testing.T) {
 			Password: test.GHEPassword,
 		}
 
-		out, err := DownloadTemplate(dest, url, gitCredentials)
+		out, err := DownloadTemplate(dest, url, gitCredentials, TemplateOptions{})
 
 		assert.NotNil(t, out)
 		assert.Nil(t, err)
@@ -77,7 +77,7 @@ func TestDownloadTemplate(t *testing.T) {
 			Password: "badpassword",
 		}
 
-		out, err := DownloadTemplate(dest, url, gitCredentials)
+		out, err := DownloadTemplate(dest, url, gitCredentials, TemplateOptions{})
 
 		assert.Nil(t, out)
 		assert.Equal(t, err.Desc, "unexpected status code: 401 Unauthorized")
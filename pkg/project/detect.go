@@ -0,0 +1,275 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// defaultExtensionDetectorConfidence is used when an extension's detector does not give a confidence
+const defaultExtensionDetectorConfidence = 50
+
+type (
+	// DetectionCandidate : A language and build type a project may have, with how sure the detector is and why
+	DetectionCandidate struct {
+		Language   string   `json:"language"`
+		BuildType  string   `json:"projectType"`
+		Confidence int      `json:"confidence"`
+		Evidence   []string `json:"evidence"`
+		Detector   string   `json:"detector"`
+	}
+
+	// projectDetector recognises a single language or build type
+	projectDetector struct {
+		Name string
+		// Detect returns a candidate if the project looks like the detector's type, or nil if it does not
+		Detect func(projectPath string) *DetectionCandidate
+	}
+)
+
+// detectorRegistry is every built-in detector. When candidates have the same confidence,
+// the one from the detector registered first is ranked highest, so a spring boot pom.xml is
+// built as spring even when its Dockerfile is based on websphere-liberty.
+var detectorRegistry = []projectDetector{
+	{"spring", detectSpring},
+	{"liberty", detectLiberty},
+	{"maven", detectMaven},
+	{"gradle", detectGradle},
+	{"node", detectNode},
+	{"swift", detectSwift},
+	{"python", detectPython},
+	{"go", detectGo},
+	{"dotnet", detectDotnet},
+	{"rust", detectRust},
+}
+
+// detectProject runs the registered detectors, and any extra detectors, against a project,
+// returning their candidates ranked from most to least confident
+func detectProject(projectPath string, extraDetectors []projectDetector) []DetectionCandidate {
+	candidates := []DetectionCandidate{}
+	for _, detector := range append(append([]projectDetector{}, detectorRegistry...), extraDetectors...) {
+		if candidate := detector.Detect(projectPath); candidate != nil {
+			candidate.Detector = detector.Name
+			candidates = append(candidates, *candidate)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates
+}
+
+// extensionDetectors creates a detector for every detector an extension declares
+func extensionDetectors(extensions []utils.Extension) []projectDetector {
+	detectors := []projectDetector{}
+	for _, extension := range extensions {
+		for _, declared := range extension.Detectors {
+			detectors = append(detectors, newExtensionDetector(extension.ProjectType, declared))
+		}
+	}
+	return detectors
+}
+
+// detectionFileDetectors creates a detector for the detection file of every extension that names one,
+// which is how extensions recognised their projects before they could declare detectors
+func detectionFileDetectors(extensions []utils.Extension) []projectDetector {
+	detectors := []projectDetector{}
+	for _, extension := range extensions {
+		if extension.Detection != "" {
+			detectors = append(detectors, newExtensionDetector(extension.ProjectType, utils.ExtensionDetector{Files: []string{extension.Detection}}))
+		}
+	}
+	return detectors
+}
+
+// newExtensionDetector creates a detector that matches when every file pattern an extension declares matches
+func newExtensionDetector(projectType string, declared utils.ExtensionDetector) projectDetector {
+	return projectDetector{
+		Name: "extension:" + projectType,
+		Detect: func(projectPath string) *DetectionCandidate {
+			if len(declared.Files) == 0 {
+				return nil
+			}
+			evidence := []string{}
+			matchedFiles := []string{}
+			for _, pattern := range declared.Files {
+				matches, err := filepath.Glob(filepath.Join(projectPath, filepath.FromSlash(pattern)))
+				if err != nil || len(matches) == 0 {
+					return nil
+				}
+				matchedFiles = append(matchedFiles, matches...)
+				evidence = append(evidence, relativeTo(projectPath, matches[0]))
+			}
+			if declared.Contains != "" {
+				found := ""
+				for _, file := range matchedFiles {
+					if fileContains(file, declared.Contains) {
+						found = file
+						break
+					}
+				}
+				if found == "" {
+					return nil
+				}
+				evidence = append(evidence, relativeTo(projectPath, found)+" contains "+declared.Contains)
+			}
+
+			confidence := declared.Confidence
+			if confidence <= 0 {
+				confidence = defaultExtensionDetectorConfidence
+			} else if confidence > 100 {
+				confidence = 100
+			}
+			language := declared.Language
+			if language == "" {
+				language = "unknown"
+			}
+			return &DetectionCandidate{Language: language, BuildType: projectType, Confidence: confidence, Evidence: evidence}
+		},
+	}
+}
+
+func detectLiberty(projectPath string) *DetectionCandidate {
+	if !utils.PathExists(filepath.Join(projectPath, "pom.xml")) {
+		return nil
+	}
+	if fileContains(filepath.Join(projectPath, "Dockerfile"), "FROM websphere-liberty") {
+		return &DetectionCandidate{Language: "java", BuildType: "liberty", Confidence: 95, Evidence: []string{"pom.xml", "Dockerfile is based on websphere-liberty"}}
+	}
+	if utils.PathExists(filepath.Join(projectPath, "src", "main", "liberty", "config", "server.xml")) {
+		return &DetectionCandidate{Language: "java", BuildType: "liberty", Confidence: 90, Evidence: []string{"pom.xml", "src/main/liberty/config/server.xml"}}
+	}
+	return nil
+}
+
+// detectSpring only recognises maven projects, as PFE builds spring projects with maven.
+// Spring boot projects built with gradle are left to detectGradle.
+func detectSpring(projectPath string) *DetectionCandidate {
+	if fileContains(filepath.Join(projectPath, "pom.xml"), "<groupId>org.springframework.boot</groupId>") {
+		return &DetectionCandidate{Language: "java", BuildType: "spring", Confidence: 95, Evidence: []string{"pom.xml depends on org.springframework.boot"}}
+	}
+	return nil
+}
+
+func detectMaven(projectPath string) *DetectionCandidate {
+	if utils.PathExists(filepath.Join(projectPath, "pom.xml")) {
+		return &DetectionCandidate{Language: "java", BuildType: "docker", Confidence: 85, Evidence: []string{"pom.xml"}}
+	}
+	return nil
+}
+
+func detectGradle(projectPath string) *DetectionCandidate {
+	if evidence := existingFiles(projectPath, "build.gradle", "build.gradle.kts", "settings.gradle"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "java", BuildType: "docker", Confidence: 70, Evidence: evidence}
+	}
+	return nil
+}
+
+func detectNode(projectPath string) *DetectionCandidate {
+	if utils.PathExists(filepath.Join(projectPath, "package.json")) {
+		return &DetectionCandidate{Language: "javascript", BuildType: "nodejs", Confidence: 80, Evidence: []string{"package.json"}}
+	}
+	return nil
+}
+
+func detectSwift(projectPath string) *DetectionCandidate {
+	if utils.PathExists(filepath.Join(projectPath, "Package.swift")) {
+		return &DetectionCandidate{Language: "swift", BuildType: "swift", Confidence: 75, Evidence: []string{"Package.swift"}}
+	}
+	return nil
+}
+
+func detectPython(projectPath string) *DetectionCandidate {
+	if evidence := existingFiles(projectPath, "requirements.txt", "setup.py", "Pipfile", "pyproject.toml"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "python", BuildType: "docker", Confidence: 60, Evidence: evidence}
+	}
+	if evidence := topLevelFilesWithExtension(projectPath, ".py"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "python", BuildType: "docker", Confidence: 40, Evidence: evidence}
+	}
+	return nil
+}
+
+func detectGo(projectPath string) *DetectionCandidate {
+	if utils.PathExists(filepath.Join(projectPath, "go.mod")) {
+		return &DetectionCandidate{Language: "go", BuildType: "docker", Confidence: 60, Evidence: []string{"go.mod"}}
+	}
+	if evidence := topLevelFilesWithExtension(projectPath, ".go"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "go", BuildType: "docker", Confidence: 40, Evidence: evidence}
+	}
+	return nil
+}
+
+func detectDotnet(projectPath string) *DetectionCandidate {
+	if evidence := topLevelFilesWithExtension(projectPath, ".csproj"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "csharp", BuildType: "docker", Confidence: 60, Evidence: evidence}
+	}
+	if evidence := topLevelFilesWithExtension(projectPath, ".fsproj"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "fsharp", BuildType: "docker", Confidence: 60, Evidence: evidence}
+	}
+	if evidence := topLevelFilesWithExtension(projectPath, ".sln"); len(evidence) > 0 {
+		return &DetectionCandidate{Language: "csharp", BuildType: "docker", Confidence: 50, Evidence: evidence}
+	}
+	return nil
+}
+
+func detectRust(projectPath string) *DetectionCandidate {
+	if utils.PathExists(filepath.Join(projectPath, "Cargo.toml")) {
+		return &DetectionCandidate{Language: "rust", BuildType: "docker", Confidence: 60, Evidence: []string{"Cargo.toml"}}
+	}
+	return nil
+}
+
+// existingFiles returns the names that exist in the root of the project
+func existingFiles(projectPath string, names ...string) []string {
+	existing := []string{}
+	for _, name := range names {
+		if utils.PathExists(filepath.Join(projectPath, name)) {
+			existing = append(existing, name)
+		}
+	}
+	return existing
+}
+
+// topLevelFilesWithExtension returns the files in the root of the project with the given extension
+func topLevelFilesWithExtension(projectPath string, extension string) []string {
+	matching := []string{}
+	projectFiles, err := ioutil.ReadDir(projectPath)
+	if err != nil {
+		return matching
+	}
+	for _, file := range projectFiles {
+		if !file.IsDir() && filepath.Ext(file.Name()) == extension {
+			matching = append(matching, file.Name())
+		}
+	}
+	return matching
+}
+
+// fileContains returns true if the file can be read and contains text
+func fileContains(filename string, text string) bool {
+	contents, err := ioutil.ReadFile(filename)
+	return err == nil && strings.Contains(string(contents), text)
+}
+
+// relativeTo returns path relative to the project, using / as the separator
+func relativeTo(projectPath string, path string) string {
+	relativePath, err := filepath.Rel(projectPath, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(relativePath)
+}
@@ -0,0 +1,198 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// createDetectTestProject creates a project containing the given files and their contents
+func createDetectTestProject(t *testing.T, files map[string]string) string {
+	projectPath, err := ioutil.TempDir("", "detect_test")
+	if err != nil {
+		t.Fatalf("unable to create test project: %v", err)
+	}
+	for name, contents := range files {
+		filename := filepath.Join(projectPath, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(filename), 0777)
+		ioutil.WriteFile(filename, []byte(contents), 0644)
+	}
+	return projectPath
+}
+
+func TestDetectProject(t *testing.T) {
+	tests := map[string]struct {
+		files         map[string]string
+		wantLanguage  string
+		wantBuildType string
+		wantDetector  string
+		wantEvidence  []string
+	}{
+		"liberty from its server.xml": {
+			files:         map[string]string{"pom.xml": "", "src/main/liberty/config/server.xml": ""},
+			wantLanguage:  "java",
+			wantBuildType: "liberty",
+			wantDetector:  "liberty",
+			wantEvidence:  []string{"pom.xml", "src/main/liberty/config/server.xml"},
+		},
+		"spring boot built with gradle is a docker project": {
+			files:         map[string]string{"build.gradle": "id 'org.springframework.boot' version '2.2.0'"},
+			wantLanguage:  "java",
+			wantBuildType: "docker",
+			wantDetector:  "gradle",
+			wantEvidence:  []string{"build.gradle"},
+		},
+		"spring ahead of liberty when both match": {
+			files: map[string]string{
+				"pom.xml":    "<groupId>org.springframework.boot</groupId>",
+				"Dockerfile": "FROM websphere-liberty:latest",
+			},
+			wantLanguage:  "java",
+			wantBuildType: "spring",
+			wantDetector:  "spring",
+			wantEvidence:  []string{"pom.xml depends on org.springframework.boot"},
+		},
+		"plain maven": {
+			files:         map[string]string{"pom.xml": "<project></project>"},
+			wantLanguage:  "java",
+			wantBuildType: "docker",
+			wantDetector:  "maven",
+			wantEvidence:  []string{"pom.xml"},
+		},
+		"plain gradle": {
+			files:         map[string]string{"build.gradle": "", "settings.gradle": ""},
+			wantLanguage:  "java",
+			wantBuildType: "docker",
+			wantDetector:  "gradle",
+			wantEvidence:  []string{"build.gradle", "settings.gradle"},
+		},
+		"python from its requirements": {
+			files:         map[string]string{"requirements.txt": "flask", "app.py": ""},
+			wantLanguage:  "python",
+			wantBuildType: "docker",
+			wantDetector:  "python",
+			wantEvidence:  []string{"requirements.txt"},
+		},
+		"go from its module": {
+			files:         map[string]string{"go.mod": "module example.com/app", "main.go": ""},
+			wantLanguage:  "go",
+			wantBuildType: "docker",
+			wantDetector:  "go",
+			wantEvidence:  []string{"go.mod"},
+		},
+		".NET from its project file": {
+			files:         map[string]string{"app.csproj": ""},
+			wantLanguage:  "csharp",
+			wantBuildType: "docker",
+			wantDetector:  "dotnet",
+			wantEvidence:  []string{"app.csproj"},
+		},
+		"rust from its manifest": {
+			files:         map[string]string{"Cargo.toml": ""},
+			wantLanguage:  "rust",
+			wantBuildType: "docker",
+			wantDetector:  "rust",
+			wantEvidence:  []string{"Cargo.toml"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectPath := createDetectTestProject(t, test.files)
+			defer os.RemoveAll(projectPath)
+
+			candidates := detectProject(projectPath, nil)
+			assert.NotEmpty(t, candidates)
+			assert.Equal(t, test.wantLanguage, candidates[0].Language)
+			assert.Equal(t, test.wantBuildType, candidates[0].BuildType)
+			assert.Equal(t, test.wantDetector, candidates[0].Detector)
+			assert.Equal(t, test.wantEvidence, candidates[0].Evidence)
+		})
+	}
+
+	t.Run("candidates are ranked from most to least confident", func(t *testing.T) {
+		candidates := detectProject(path.Join("../..", "resources", "test", "liberty-project"), nil)
+		detectors := []string{}
+		for i, candidate := range candidates {
+			detectors = append(detectors, candidate.Detector)
+			if i > 0 {
+				assert.True(t, candidates[i-1].Confidence >= candidate.Confidence)
+			}
+		}
+		assert.Equal(t, []string{"liberty", "maven"}, detectors)
+	})
+
+	t.Run("an unrecognised project has no candidates", func(t *testing.T) {
+		projectPath := createDetectTestProject(t, map[string]string{"README.md": ""})
+		defer os.RemoveAll(projectPath)
+		assert.Equal(t, []DetectionCandidate{}, detectProject(projectPath, nil))
+	})
+}
+
+func TestExtensionDetectors(t *testing.T) {
+	projectPath := createDetectTestProject(t, map[string]string{
+		"app.config":  "runtime: example",
+		"src/main.ex": "",
+	})
+	defer os.RemoveAll(projectPath)
+
+	extensions := []utils.Extension{
+		{
+			ProjectType: "example",
+			Detectors: []utils.ExtensionDetector{
+				{Language: "elixir", Files: []string{"app.config", "src/*.ex"}, Contains: "runtime: example", Confidence: 90},
+			},
+		},
+		{
+			ProjectType: "missing",
+			Detectors: []utils.ExtensionDetector{
+				{Files: []string{"app.config", "missing.txt"}},
+			},
+		},
+		{
+			ProjectType: "wrongcontents",
+			Detectors: []utils.ExtensionDetector{
+				{Files: []string{"app.config"}, Contains: "runtime: other"},
+			},
+		},
+		{
+			ProjectType: "defaults",
+			Detectors: []utils.ExtensionDetector{
+				{Files: []string{"*.config"}},
+			},
+		},
+	}
+
+	candidates := detectProject(projectPath, extensionDetectors(extensions))
+	assert.Equal(t, []DetectionCandidate{
+		{
+			Language:   "elixir",
+			BuildType:  "example",
+			Confidence: 90,
+			Evidence:   []string{"app.config", "src/main.ex", "app.config contains runtime: example"},
+			Detector:   "extension:example",
+		},
+		{
+			Language:   "unknown",
+			BuildType:  "defaults",
+			Confidence: defaultExtensionDetectorConfidence,
+			Evidence:   []string{"app.config"},
+			Detector:   "extension:defaults",
+		},
+	}, candidates)
+}
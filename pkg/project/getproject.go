@@ -14,12 +14,15 @@ import (
 type (
 	// Project : Represents a project
 	Project struct {
-		ProjectID      string `json:"projectID"`
-		Name           string `json:"name"`
-		Language       string `json:"language"`
-		Host           string `json:"host"`
-		LocationOnDisk string `json:"locOnDisk"`
-		AppStatus      string `json:"appStatus"`
+		ProjectID           string `json:"projectID"`
+		Name                string `json:"name"`
+		Language            string `json:"language"`
+		ProjectType         string `json:"projectType"`
+		Host                string `json:"host"`
+		LocationOnDisk      string `json:"locOnDisk"`
+		AppStatus           string `json:"appStatus"`
+		BuildStatus         string `json:"buildStatus"`
+		DetailedBuildStatus string `json:"detailedBuildStatus,omitempty"`
 	}
 )
 
@@ -0,0 +1,294 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	logr "github.com/sirupsen/logrus"
+)
+
+// Sources of ignore rules, reported when explaining why a path was not synced
+const (
+	ignoreSourceBuiltIn    = "built-in"
+	ignoreSourceCwSettings = ".cw-settings"
+	ignoreSourceRefPaths   = ".cw-refpaths.json"
+	ignoreFileDocker       = ".dockerignore"
+	ignoreFileGit          = ".gitignore"
+	ignoreFileCodewind     = ".cwignore"
+)
+
+// List of files that will not be sent to PFE
+var defaultIgnoredFiles = []string{
+	".DS_Store",
+	"*.swp",
+	"*.swx",
+	"Jenkinsfile",
+	".cfignore",
+	"localm2cache.zip",
+	"libertyrepocache.zip",
+	"run-dev",
+	"run-debug",
+	"manifest.yml",
+	"idt.js",
+	".bluemix",
+	".build-ubuntu",
+	".yo-rc.json",
+	"*.iml",
+	".project",
+	".classpath",
+	".options",
+}
+
+// List of directories that will not be sent to PFE
+var defaultIgnoredDirectories = []string{
+	".project",
+	"node_modules*",
+	".git*",
+	"load-test*",
+	".settings",
+	"Dockerfile-tools",
+	"target",
+	"mc-target",
+	".m2",
+	"debian",
+	".bluemix",
+	"terraform",
+	".build-ubuntu",
+	".idea",
+	".vscode",
+}
+
+type (
+	// ignoreRule is a single gitignore style pattern
+	ignoreRule struct {
+		Pattern   string // the pattern as written
+		Source    string // where the pattern was read from
+		negate    bool   // the pattern started with !, so matching paths are synced
+		dirOnly   bool   // the pattern ended with /, so only matches directories
+		filesOnly bool   // only matches files, used by the built-in file list
+		regexp    *regexp.Regexp
+	}
+
+	// ignoreMatcher decides which paths in a project are not synced. Rules are checked in
+	// order and, as with .gitignore, the last rule that matches a path decides whether it is ignored.
+	ignoreMatcher struct {
+		rules []ignoreRule
+	}
+
+	// ExcludedPath : A path that was not synced, and the rule that excluded it
+	ExcludedPath struct {
+		Path   string `json:"path"`
+		Rule   string `json:"rule"`
+		Source string `json:"source"`
+	}
+)
+
+// newIgnoreMatcher creates a matcher containing the built-in rules
+func newIgnoreMatcher() *ignoreMatcher {
+	m := &ignoreMatcher{}
+	for _, pattern := range defaultIgnoredFiles {
+		if rule, ok := newIgnoreRule(pattern, ignoreSourceBuiltIn, true); ok {
+			rule.filesOnly = true
+			m.rules = append(m.rules, *rule)
+		}
+	}
+	for _, pattern := range defaultIgnoredDirectories {
+		if rule, ok := newIgnoreRule(pattern, ignoreSourceBuiltIn, true); ok {
+			rule.dirOnly = true
+			m.rules = append(m.rules, *rule)
+		}
+	}
+	return m
+}
+
+// newProjectIgnoreMatcher creates a matcher for a project. The built-in rules come first, followed by
+// the .cw-settings ignoredPaths, then .dockerignore and .gitignore if options ask for them, then .cwignore.
+func newProjectIgnoreMatcher(projectPath string, options SyncOptions) *ignoreMatcher {
+	m := newIgnoreMatcher()
+	m.addCwSettingsPatterns(retrieveIgnoredPathsList(projectPath))
+
+	ignoreFiles := []string{}
+	if options.UseDockerignore {
+		ignoreFiles = append(ignoreFiles, ignoreFileDocker)
+	}
+	if options.UseGitignore {
+		ignoreFiles = append(ignoreFiles, ignoreFileGit)
+	}
+	ignoreFiles = append(ignoreFiles, ignoreFileCodewind)
+
+	for _, ignoreFile := range ignoreFiles {
+		// .dockerignore patterns are always relative to the root of the build context
+		anchored := ignoreFile == ignoreFileDocker
+		err := m.addIgnoreFile(filepath.Join(projectPath, ignoreFile), ignoreFile, anchored)
+		if err != nil {
+			logr.Warnf("Unable to read %v, its rules will not be used: %v", ignoreFile, err)
+		}
+	}
+	return m
+}
+
+// isIgnoreFile returns true if changes to the file at relativePath can change what is ignored
+func isIgnoreFile(relativePath string) bool {
+	switch relativePath {
+	case ".cw-settings", ignoreFileDocker, ignoreFileGit, ignoreFileCodewind:
+		return true
+	}
+	return false
+}
+
+// addCwSettingsPatterns adds the ignoredPaths from .cw-settings, which are always matched from the project root
+func (m *ignoreMatcher) addCwSettingsPatterns(patterns []string) {
+	for _, pattern := range patterns {
+		if rule, ok := newIgnoreRule(filepath.ToSlash(filepath.Clean(pattern)), ignoreSourceCwSettings, true); ok {
+			m.rules = append(m.rules, *rule)
+		}
+	}
+}
+
+// addIgnoreFile adds a rule for every pattern in an ignore file, a missing file adds nothing
+func (m *ignoreMatcher) addIgnoreFile(filename string, source string, anchored bool) error {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if rule, ok := newIgnoreRule(scanner.Text(), source+":"+strconv.Itoa(lineNumber), anchored); ok {
+			m.rules = append(m.rules, *rule)
+		}
+	}
+	return scanner.Err()
+}
+
+// withRules returns a copy of the matcher with extra rules added to the end
+func (m *ignoreMatcher) withRules(patterns []string, source string) *ignoreMatcher {
+	copied := &ignoreMatcher{rules: append([]ignoreRule{}, m.rules...)}
+	for _, pattern := range patterns {
+		if rule, ok := newIgnoreRule(pattern, source, true); ok {
+			copied.rules = append(copied.rules, *rule)
+		}
+	}
+	return copied
+}
+
+// match returns the rule that ignores relativePath, or nil if the path should be synced
+func (m *ignoreMatcher) match(relativePath string, isDir bool) *ignoreRule {
+	var matched *ignoreRule
+	for i := range m.rules {
+		rule := &m.rules[i]
+		if (rule.dirOnly && !isDir) || (rule.filesOnly && isDir) {
+			continue
+		}
+		if rule.regexp.MatchString(relativePath) {
+			matched = rule
+		}
+	}
+	if matched == nil || matched.negate {
+		return nil
+	}
+	return matched
+}
+
+// isIgnored returns true if relativePath should not be synced
+func (m *ignoreMatcher) isIgnored(relativePath string, isDir bool) bool {
+	return m.match(relativePath, isDir) != nil
+}
+
+// newIgnoreRule parses a gitignore style pattern. Patterns containing a / other than at the end only
+// match from the project root, as do all patterns if anchored is set. It returns false for blank lines,
+// comments and patterns that cannot be parsed.
+func newIgnoreRule(pattern string, source string, anchored bool) (*ignoreRule, bool) {
+	line := strings.TrimRight(pattern, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+
+	rule := ignoreRule{Pattern: line, Source: source}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		anchored = true
+		line = strings.TrimLeft(line, "/")
+	} else if strings.Contains(line, "/") {
+		anchored = true
+	}
+	if line == "" {
+		return nil, false
+	}
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	compiled, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, false
+	}
+	rule.regexp = compiled
+	return &rule, true
+}
+
+// globToRegexp converts a glob to a regular expression, where ** matches any number of directories
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			expr.WriteString(regexp.QuoteMeta(string(glob[i+1])))
+			i++
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
@@ -0,0 +1,162 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreRule(t *testing.T) {
+	tests := map[string]struct {
+		pattern         string
+		anchored        bool
+		path            string
+		isDir           bool
+		shouldBeMatched bool
+	}{
+		"unanchored pattern matches at any depth": {
+			pattern: "*.log", path: "logs/today/app.log", shouldBeMatched: true,
+		},
+		"anchored pattern only matches from the root": {
+			pattern: "*.log", anchored: true, path: "logs/app.log", shouldBeMatched: false,
+		},
+		"leading slash anchors the pattern": {
+			pattern: "/build", path: "src/build", isDir: true, shouldBeMatched: false,
+		},
+		"leading slash matches at the root": {
+			pattern: "/build", path: "build", isDir: true, shouldBeMatched: true,
+		},
+		"pattern containing a slash is anchored": {
+			pattern: "docs/*.md", path: "other/docs/readme.md", shouldBeMatched: false,
+		},
+		"single star does not cross directories": {
+			pattern: "docs/*.md", path: "docs/api/readme.md", shouldBeMatched: false,
+		},
+		"leading double star matches in any directory": {
+			pattern: "**/fixtures", path: "a/b/fixtures", isDir: true, shouldBeMatched: true,
+		},
+		"middle double star matches no directories": {
+			pattern: "src/**/test.js", path: "src/test.js", shouldBeMatched: true,
+		},
+		"middle double star matches many directories": {
+			pattern: "src/**/test.js", path: "src/a/b/test.js", shouldBeMatched: true,
+		},
+		"trailing double star matches everything inside": {
+			pattern: "dist/**", path: "dist/js/app.js", shouldBeMatched: true,
+		},
+		"trailing slash only matches directories": {
+			pattern: "out/", path: "out", isDir: false, shouldBeMatched: false,
+		},
+		"trailing slash matches a directory": {
+			pattern: "out/", path: "deep/out", isDir: true, shouldBeMatched: true,
+		},
+		"question mark matches one character": {
+			pattern: "file?.txt", path: "file1.txt", shouldBeMatched: true,
+		},
+		"character class matches": {
+			pattern: "file[0-9].txt", path: "file7.txt", shouldBeMatched: true,
+		},
+		"negated character class matches": {
+			pattern: "file[!0-9].txt", path: "file7.txt", shouldBeMatched: false,
+		},
+		"escaped characters match literally": {
+			pattern: `\#notacomment`, path: "#notacomment", shouldBeMatched: true,
+		},
+		"dots match literally": {
+			pattern: "a.b", path: "axb", shouldBeMatched: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule, ok := newIgnoreRule(test.pattern, "test", test.anchored)
+			assert.True(t, ok)
+			m := ignoreMatcher{rules: []ignoreRule{*rule}}
+			assert.Equal(t, test.shouldBeMatched, m.isIgnored(test.path, test.isDir))
+		})
+	}
+
+	t.Run("blank lines and comments are not rules", func(t *testing.T) {
+		for _, pattern := range []string{"", "   ", "# a comment", "/", "!"} {
+			_, ok := newIgnoreRule(pattern, "test", false)
+			assert.False(t, ok, "pattern %q should not be a rule", pattern)
+		}
+	})
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	t.Run("later negated rule includes the path again", func(t *testing.T) {
+		m := newIgnoreMatcher().withRules([]string{"*.txt", "!keep.txt"}, "test")
+		assert.True(t, m.isIgnored("notes.txt", false))
+		assert.False(t, m.isIgnored("keep.txt", false))
+	})
+
+	t.Run("negated rule can include a built-in default", func(t *testing.T) {
+		m := newIgnoreMatcher().withRules([]string{"!Jenkinsfile"}, "test")
+		assert.False(t, m.isIgnored("Jenkinsfile", false))
+	})
+
+	t.Run("match reports the rule that excluded the path", func(t *testing.T) {
+		m := newIgnoreMatcher().withRules([]string{"*.tmp"}, "test")
+		rule := m.match("a.tmp", false)
+		assert.Equal(t, "*.tmp", rule.Pattern)
+		assert.Equal(t, "test", rule.Source)
+
+		rule = m.match("node_modules", true)
+		assert.Equal(t, "node_modules*", rule.Pattern)
+		assert.Equal(t, ignoreSourceBuiltIn, rule.Source)
+	})
+
+	t.Run("withRules does not change the original matcher", func(t *testing.T) {
+		m := newIgnoreMatcher()
+		m.withRules([]string{"*.tmp"}, "test")
+		assert.False(t, m.isIgnored("a.tmp", false))
+	})
+}
+
+func TestNewProjectIgnoreMatcher(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "ignore_test")
+	defer os.RemoveAll(projectPath)
+
+	ioutil.WriteFile(filepath.Join(projectPath, ".cw-settings"), []byte(`{"ignoredPaths": ["settings.txt"]}`), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, ".gitignore"), []byte("# comment\n*.git.txt\n"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, ".dockerignore"), []byte("*.docker.txt\n"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, ".cwignore"), []byte("*.cw.txt\n!settings.txt\n"), 0644)
+
+	t.Run(".gitignore and .dockerignore are only used when asked for", func(t *testing.T) {
+		m := newProjectIgnoreMatcher(projectPath, SyncOptions{})
+		assert.False(t, m.isIgnored("a.git.txt", false))
+		assert.False(t, m.isIgnored("a.docker.txt", false))
+		assert.True(t, m.isIgnored("a.cw.txt", false))
+	})
+
+	t.Run("rules are reported with the file and line they came from", func(t *testing.T) {
+		m := newProjectIgnoreMatcher(projectPath, SyncOptions{UseGitignore: true, UseDockerignore: true})
+		assert.Equal(t, ".gitignore:2", m.match("nested/a.git.txt", false).Source)
+		assert.Equal(t, ".dockerignore:1", m.match("a.docker.txt", false).Source)
+		assert.Equal(t, ".cwignore:1", m.match("a.cw.txt", false).Source)
+	})
+
+	t.Run(".dockerignore patterns only match from the project root", func(t *testing.T) {
+		m := newProjectIgnoreMatcher(projectPath, SyncOptions{UseDockerignore: true})
+		assert.False(t, m.isIgnored("nested/a.docker.txt", false))
+	})
+
+	t.Run(".cwignore overrides .cw-settings", func(t *testing.T) {
+		m := newProjectIgnoreMatcher(projectPath, SyncOptions{})
+		assert.False(t, m.isIgnored("settings.txt", false))
+	})
+}
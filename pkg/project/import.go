@@ -0,0 +1,243 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// DefaultImportDepth is how many directories below the workspace are searched for projects when no depth is given
+const DefaultImportDepth = 3
+
+// The status of each project found in a workspace
+const (
+	ImportStatusPlanned = "planned" // found and would be bound, reported by a dry run
+	ImportStatusBound   = "bound"   // bound to the connection
+	ImportStatusFailed  = "failed"  // its extension's validation, writing its .cw-settings or the bind failed
+	ImportStatusSkipped = "skipped" // found, but not bound
+)
+
+type (
+	// ImportedProject : The plan for, and result of, importing one project from a workspace
+	ImportedProject struct {
+		Path      string `json:"projectPath"`
+		Name      string `json:"name"`
+		Language  string `json:"language"`
+		BuildType string `json:"projectType"`
+		Detector  string `json:"detector,omitempty"`
+		Status    string `json:"status"`
+		ProjectID string `json:"projectID,omitempty"`
+		Error     string `json:"error,omitempty"`
+	}
+
+	// ImportWorkspaceResponse : The projects found in a workspace and what happened to each of them
+	ImportWorkspaceResponse struct {
+		Workspace    string            `json:"workspace"`
+		ConnectionID string            `json:"connectionID"`
+		DryRun       bool              `json:"dryRun"`
+		Projects     []ImportedProject `json:"projects"`
+		Bound        int               `json:"bound"`
+		Failed       int               `json:"failed"`
+		Skipped      int               `json:"skipped"`
+	}
+)
+
+// ignoredWorkspaceDirs are never searched for projects, as they hold dependencies or build output
+var ignoredWorkspaceDirs = map[string]bool{
+	"node_modules": true,
+	"target":       true,
+	"build":        true,
+	"bin":          true,
+	"obj":          true,
+	"vendor":       true,
+}
+
+// ImportWorkspace : Find every project in a workspace directory, validate them and bind them to a connection.
+// onPlan is given the projects that were found before any of them are bound.
+func ImportWorkspace(c *cli.Context, onPlan func(*ImportWorkspaceResponse)) (*ImportWorkspaceResponse, *ProjectError) {
+	workspace := strings.TrimSpace(c.String("workspace"))
+	if workspace == "" {
+		err := errors.New(textImportSource)
+		return nil, &ProjectError{errOpInvalidOptions, err, textImportSource}
+	}
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return importWorkspace(c, &http.Client{}, conInfo, conURL, workspace, onPlan)
+}
+
+func importWorkspace(c *cli.Context, httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, workspace string, onPlan func(*ImportWorkspaceResponse)) (*ImportWorkspaceResponse, *ProjectError) {
+	depth := DefaultImportDepth
+	if c.IsSet("depth") {
+		depth = c.Int("depth")
+	}
+	extensions, err := apiroutes.GetExtensionsFromConnection(conInfo, conURL, httpClient)
+	if err != nil {
+		log.Println("There was a problem retrieving extensions data")
+	}
+
+	plan, projErr := PlanWorkspaceImport(workspace, depth, extensions)
+	if projErr != nil {
+		return nil, projErr
+	}
+	plan.ConnectionID = conInfo.ID
+	plan.DryRun = c.Bool("dry-run")
+	onPlan(plan)
+	if plan.DryRun {
+		return plan, nil
+	}
+	importPlannedProjects(httpClient, conInfo, conURL, extensions, plan, c.Int("parallel"), syncOptionsFromContext(c))
+	return plan, nil
+}
+
+// PlanWorkspaceImport : Find the projects in a workspace and the language and type each would be bound with,
+// using the detectors and detection files of the given extensions as well as the built-in detectors.
+// A directory that is recognised as a project is not searched any further.
+func PlanWorkspaceImport(workspace string, depth int, extensions []utils.Extension) (*ImportWorkspaceResponse, *ProjectError) {
+	info, err := os.Stat(workspace)
+	if err != nil {
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+	if !info.IsDir() {
+		err = errors.New(textWorkspaceNotDir)
+		return nil, &ProjectError{errBadPath, err, textWorkspaceNotDir}
+	}
+	workspace, _ = filepath.Abs(workspace)
+
+	response := &ImportWorkspaceResponse{Workspace: workspace, Projects: []ImportedProject{}}
+	detectors := append(extensionDetectors(extensions), detectionFileDetectors(extensions)...)
+	findWorkspaceProjects(workspace, depth, detectors, response)
+
+	// names must be unique on a connection, so only the first project with each name is bound
+	pathsByName := map[string]string{}
+	for i := range response.Projects {
+		project := &response.Projects[i]
+		// as in validateProject, a project holding an extension's detection file has the extension's type
+		if extension := detectedExtension(extensions, project.Path); extension != nil {
+			project.BuildType = extension.ProjectType
+		}
+		if firstPath, used := pathsByName[project.Name]; used {
+			project.Status = ImportStatusSkipped
+			project.Error = "the name " + project.Name + " is already used by " + firstPath
+			response.Skipped++
+			continue
+		}
+		pathsByName[project.Name] = project.Path
+	}
+	return response, nil
+}
+
+// minImportConfidence is the confidence a detector needs for a directory to be taken as a project without searching inside it.
+// Weaker matches, such as a loose *.py or *.go file, are only taken when no project is found below them.
+const minImportConfidence = 50
+
+// findWorkspaceProjects adds every directory up to depth levels below dir that a detector recognises
+func findWorkspaceProjects(dir string, depth int, detectors []projectDetector, response *ImportWorkspaceResponse) {
+	candidates := detectProject(dir, detectors)
+	if len(candidates) > 0 && candidates[0].Confidence >= minImportConfidence {
+		response.Projects = append(response.Projects, importedProject(dir, candidates[0]))
+		return
+	}
+
+	found := len(response.Projects)
+	if depth > 0 {
+		files, _ := ioutil.ReadDir(dir)
+		// ReadDir returns the directories sorted, so projects are found in a stable order
+		for _, file := range files {
+			if !file.IsDir() || strings.HasPrefix(file.Name(), ".") || ignoredWorkspaceDirs[file.Name()] {
+				continue
+			}
+			findWorkspaceProjects(filepath.Join(dir, file.Name()), depth-1, detectors, response)
+		}
+	}
+	if len(candidates) > 0 && len(response.Projects) == found {
+		response.Projects = append(response.Projects, importedProject(dir, candidates[0]))
+	}
+}
+
+// importedProject plans the import of the project in dir, as the detector that recognised it
+func importedProject(dir string, candidate DetectionCandidate) ImportedProject {
+	return ImportedProject{
+		Path:      dir,
+		Name:      filepath.Base(dir),
+		Language:  candidate.Language,
+		BuildType: candidate.BuildType,
+		Detector:  candidate.Detector,
+		Status:    ImportStatusPlanned,
+	}
+}
+
+// importPlannedProjects validates and binds every planned project, parallel at a time, recording the result of each
+func importPlannedProjects(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, extensions []utils.Extension, response *ImportWorkspaceResponse, parallel int, options SyncOptions) {
+	planned := []int{}
+	for i, project := range response.Projects {
+		if project.Status == ImportStatusPlanned {
+			planned = append(planned, i)
+		}
+	}
+
+	utils.RunInParallel(len(planned), parallel, func(i int) {
+		project := &response.Projects[planned[i]]
+		// run the validation of the project's extension before binding it, as validateProject does
+		if extension := detectedExtension(extensions, project.Path); extension != nil {
+			err := runExtensionCommand(*extension, project.Path, "postProjectValidate", map[string]string{})
+			if err != nil {
+				project.Status = ImportStatusFailed
+				project.Error = err.Error()
+				return
+			}
+		}
+		projectID, projErr := bindImportedProject(httpClient, conInfo, conURL, project.Path, project.Name, project.Language, project.BuildType, options)
+		project.ProjectID = projectID
+		if projErr != nil {
+			project.Status = ImportStatusFailed
+			project.Error = projErr.Desc
+		} else {
+			project.Status = ImportStatusBound
+		}
+	})
+
+	for _, project := range response.Projects {
+		switch project.Status {
+		case ImportStatusBound:
+			response.Bound++
+		case ImportStatusFailed:
+			response.Failed++
+		}
+	}
+}
+
+// bindImportedProject writes a project's default .cw-settings if it has none, then binds it, failing if any file was not uploaded
+func bindImportedProject(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectPath string, name string, language string, buildType string, options SyncOptions) (string, *ProjectError) {
+	projErr := writeCwSettingsIfNotInProject(httpClient, conInfo, conURL, projectPath, buildType)
+	if projErr != nil {
+		return "", projErr
+	}
+	bindResponse, projectID, projErr := bind(httpClient, conInfo, conURL, projectPath, name, language, buildType, options)
+	if projErr != nil {
+		return projectID, projErr
+	}
+	return projectID, checkBindCompleted(bindResponse)
+}
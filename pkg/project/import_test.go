@@ -0,0 +1,200 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+// createTestWorkspace creates a workspace holding the given files, each path relative to the workspace
+func createTestWorkspace(t *testing.T, files ...string) string {
+	workspace, _ := ioutil.TempDir("", "import_test")
+	for _, file := range files {
+		path := filepath.Join(workspace, file)
+		os.MkdirAll(filepath.Dir(path), 0777)
+		if err := ioutil.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return workspace
+}
+
+func importTestContext(workspace string, dryRun bool, parallel int) *cli.Context {
+	set := flag.NewFlagSet("tests", 0)
+	set.String("workspace", workspace, "")
+	set.String("conid", "local", "")
+	set.Bool("dry-run", dryRun, "")
+	set.Int("parallel", parallel, "")
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestPlanWorkspaceImport(t *testing.T) {
+	workspace := createTestWorkspace(t,
+		"frontend/package.json",
+		"frontend/node_modules/left-pad/package.json",
+		"services/orders/pom.xml",
+		"services/orders/sub/package.json",
+		"services/api/go.mod",
+		"team/api/Cargo.toml",
+		"docs/README.md",
+		".hidden/package.json",
+		"a/b/c/d/package.json",
+	)
+	defer os.RemoveAll(workspace)
+
+	t.Run("success case - projects are found, but not inside other projects or ignored directories", func(t *testing.T) {
+		plan, err := PlanWorkspaceImport(workspace, DefaultImportDepth, nil)
+		assert.Nil(t, err)
+		got := []string{}
+		for _, project := range plan.Projects {
+			rel, _ := filepath.Rel(workspace, project.Path)
+			got = append(got, rel+":"+project.Name+":"+project.Language+":"+project.BuildType+":"+project.Status)
+		}
+		assert.Equal(t, []string{
+			"frontend:frontend:javascript:nodejs:planned",
+			"services/api:api:go:docker:planned",
+			"services/orders:orders:java:docker:planned",
+			"team/api:api:rust:docker:skipped",
+		}, got)
+		assert.Equal(t, 1, plan.Skipped)
+		assert.Contains(t, plan.Projects[3].Error, "the name api is already used by")
+	})
+
+	t.Run("success case - depth limits the search", func(t *testing.T) {
+		plan, _ := PlanWorkspaceImport(workspace, 1, nil)
+		assert.Len(t, plan.Projects, 1)
+		plan, _ = PlanWorkspaceImport(workspace, 4, nil)
+		assert.Len(t, plan.Projects, 5)
+	})
+
+	t.Run("success case - a directory only weakly recognised is searched, and only taken if nothing is found inside it", func(t *testing.T) {
+		weak := createTestWorkspace(t, "build.py", "frontend/package.json", "api/go.mod", "scripts/tools/main.go")
+		defer os.RemoveAll(weak)
+		plan, err := PlanWorkspaceImport(weak, DefaultImportDepth, nil)
+		assert.Nil(t, err)
+		got := []string{}
+		for _, project := range plan.Projects {
+			rel, _ := filepath.Rel(weak, project.Path)
+			got = append(got, rel+":"+project.Language)
+		}
+		assert.Equal(t, []string{"api:go", "frontend:javascript", "scripts/tools:go"}, got)
+
+		alone := createTestWorkspace(t, "main.py")
+		defer os.RemoveAll(alone)
+		plan, _ = PlanWorkspaceImport(alone, DefaultImportDepth, nil)
+		assert.Len(t, plan.Projects, 1)
+		assert.Equal(t, "python", plan.Projects[0].Language)
+	})
+
+	t.Run("success case - projects holding an extension's detection file have the extension's type", func(t *testing.T) {
+		extended := createTestWorkspace(t, "stack/.appsody-config.yaml", "stack/package.json", "bare/.appsody-config.yaml", "plain/package.json")
+		defer os.RemoveAll(extended)
+		extensions := []utils.Extension{{ProjectType: "appsodyExtension", Detection: ".appsody-config.yaml"}}
+		plan, err := PlanWorkspaceImport(extended, DefaultImportDepth, extensions)
+		assert.Nil(t, err)
+		got := []string{}
+		for _, project := range plan.Projects {
+			got = append(got, project.Name+":"+project.Language+":"+project.BuildType)
+		}
+		assert.Equal(t, []string{"bare:unknown:appsodyExtension", "plain:javascript:nodejs", "stack:javascript:appsodyExtension"}, got)
+	})
+
+	t.Run("fail case - the workspace does not exist", func(t *testing.T) {
+		_, err := PlanWorkspaceImport(filepath.Join(workspace, "missing"), DefaultImportDepth, nil)
+		assert.Equal(t, errBadPath, err.Op)
+	})
+}
+
+// clientMockImport binds each project with the ID id-<name>, answering every other request like clientMockPFE
+type clientMockImport struct {
+	clientMockPFE
+}
+
+func (c *clientMockImport) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/api/v1/projects/bind/start" {
+		return c.clientMockPFE.Do(req)
+	}
+	var bindRequest BindRequest
+	json.NewDecoder(req.Body).Decode(&bindRequest)
+	body, _ := json.Marshal(BindResponse{ProjectID: "id-" + bindRequest.Name})
+	return &http.Response{StatusCode: http.StatusAccepted, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func TestImportWorkspace(t *testing.T) {
+	workspace := createTestWorkspace(t, "one/package.json", "two/package.json", "three/go.mod")
+	defer os.RemoveAll(workspace)
+	routes := map[string]mockPFEResponse{"GET /api/v1/extensions": {http.StatusOK, []utils.Extension{}}}
+	for _, name := range []string{"one", "two", "three"} {
+		routes["PUT /api/v1/projects/id-"+name+"/upload"] = mockPFEResponse{http.StatusOK, nil}
+		routes["POST /api/v1/projects/id-"+name+"/bind/end"] = mockPFEResponse{http.StatusOK, nil}
+		defer RemoveSyncManifest("id-" + name)
+	}
+	routes["PUT /api/v1/projects/id-three/upload"] = mockPFEResponse{http.StatusBadRequest, nil}
+
+	t.Run("success case - a dry run only plans the import", func(t *testing.T) {
+		client := &clientMockImport{clientMockPFE{routes: routes}}
+		planned := 0
+		response, err := importWorkspace(importTestContext(workspace, true, 2), client, &mockConnection, "http://pfe", workspace, func(plan *ImportWorkspaceResponse) {
+			planned = len(plan.Projects)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, planned)
+		assert.True(t, response.DryRun)
+		assert.Equal(t, "local", response.ConnectionID)
+		assert.Equal(t, []string{"GET /api/v1/extensions"}, client.requests)
+	})
+
+	t.Run("success case - every project is bound in parallel and reported", func(t *testing.T) {
+		client := &clientMockImport{clientMockPFE{routes: routes}}
+		response, err := importWorkspace(importTestContext(workspace, false, 2), client, &mockConnection, "http://pfe", workspace, func(plan *ImportWorkspaceResponse) {})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, response.Bound)
+		assert.Equal(t, 1, response.Failed)
+		for _, project := range response.Projects {
+			assert.Equal(t, "id-"+project.Name, project.ProjectID)
+			if project.Name == "three" {
+				assert.Equal(t, ImportStatusFailed, project.Status)
+				assert.Contains(t, project.Error, "files failed to upload")
+			} else {
+				assert.Equal(t, ImportStatusBound, project.Status)
+			}
+		}
+	})
+
+	t.Run("fail case - a project whose extension fails to validate it is not bound", func(t *testing.T) {
+		extended := createTestWorkspace(t, "stack/.appsody-config.yaml", "stack/package.json")
+		defer os.RemoveAll(extended)
+		extensions := []utils.Extension{{
+			ProjectType: "appsodyExtension",
+			Detection:   ".appsody-config.yaml",
+			Commands:    []utils.ExtensionCommand{{Name: "postProjectValidate", Command: "missing-validator"}},
+		}}
+		client := &clientMockImport{clientMockPFE{routes: map[string]mockPFEResponse{"GET /api/v1/extensions": {http.StatusOK, extensions}}}}
+		response, err := importWorkspace(importTestContext(extended, false, 1), client, &mockConnection, "http://pfe", extended, func(plan *ImportWorkspaceResponse) {})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, response.Failed)
+		assert.Equal(t, ImportStatusFailed, response.Projects[0].Status)
+		assert.Contains(t, response.Projects[0].Error, "missing-validator")
+		assert.Equal(t, []string{"GET /api/v1/extensions"}, client.requests)
+	})
+}
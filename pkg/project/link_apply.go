@@ -0,0 +1,307 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// The changes that make a project's links match a links file
+const (
+	LinkActionCreate = "create"
+	LinkActionRename = "rename"
+	LinkActionRemove = "remove"
+)
+
+// The statuses of a link change
+const (
+	LinkChangePlanned = "planned" // would be made, reported by a dry run
+	LinkChangeApplied = "applied" // accepted by PFE
+	LinkChangeFailed  = "failed"  // rejected by PFE
+)
+
+type (
+	// LinksFile : The links that projects should have, by project name
+	LinksFile struct {
+		Projects []LinksFileProject `yaml:"projects"`
+	}
+
+	// LinksFileProject : A project and every link it should have. Links it has that are not listed are removed
+	LinksFileProject struct {
+		Name  string          `yaml:"name"`
+		Links []LinksFileLink `yaml:"links"`
+	}
+
+	// LinksFileLink : A link from the environment variable of a project to the project with the target name
+	LinksFileLink struct {
+		Env    string `yaml:"env"`
+		Target string `yaml:"target"`
+	}
+
+	// LinkChange : A link to create, rename or remove, and whether it was
+	LinkChange struct {
+		Action          string `json:"action"`
+		ProjectName     string `json:"projectName"`
+		ProjectID       string `json:"projectID"`
+		EnvName         string `json:"envName"`
+		NewEnvName      string `json:"newEnvName,omitempty"`
+		TargetName      string `json:"targetName"`
+		TargetProjectID string `json:"targetProjectID"`
+		Status          string `json:"status"`
+		Error           string `json:"error,omitempty"`
+	}
+
+	// LinkApplyResponse : The changes made, or that would be made, to converge the links of a connection's projects with a links file
+	LinkApplyResponse struct {
+		ConnectionID string       `json:"connectionID"`
+		DryRun       bool         `json:"dryRun"`
+		Changes      []LinkChange `json:"changes"`
+		Unchanged    int          `json:"unchanged"`
+		Failed       int          `json:"failed"`
+	}
+)
+
+// ApplyLinks : Create, rename and remove the links of each project named in the links file given by -f, so they match it exactly,
+// or with --dry-run only report the changes that would be made. Projects not named in the file are not changed.
+func ApplyLinks(c *cli.Context) (*LinkApplyResponse, *ProjectError) {
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	linksFile, projErr := ReadLinksFile(strings.TrimSpace(c.String("file")))
+	if projErr != nil {
+		return nil, projErr
+	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return applyLinks(http.DefaultClient, conInfo, conURL, linksFile, c.Bool("dry-run"))
+}
+
+// ReadLinksFile : Read and check a links file
+func ReadLinksFile(linksFilePath string) (*LinksFile, *ProjectError) {
+	content, err := ioutil.ReadFile(linksFilePath)
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+	var linksFile LinksFile
+	// unknown fields are most likely typos, which would otherwise remove links
+	err = yaml.UnmarshalStrict(content, &linksFile)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+
+	err = checkLinksFile(&linksFile)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	return &linksFile, nil
+}
+
+// checkLinksFile returns an error if a project or link is missing a field, or is given more than once
+func checkLinksFile(linksFile *LinksFile) error {
+	projectNames := map[string]bool{}
+	for _, project := range linksFile.Projects {
+		if project.Name == "" {
+			return errors.New("every project in the links file needs a name")
+		}
+		if projectNames[project.Name] {
+			return fmt.Errorf("project %v is in the links file more than once", project.Name)
+		}
+		projectNames[project.Name] = true
+
+		envNames := map[string]bool{}
+		for _, link := range project.Links {
+			if link.Env == "" || link.Target == "" {
+				return fmt.Errorf("every link of project %v needs an env and a target", project.Name)
+			}
+			if envNames[link.Env] {
+				return fmt.Errorf("project %v has more than one link with env %v", project.Name, link.Env)
+			}
+			envNames[link.Env] = true
+		}
+	}
+	return nil
+}
+
+// applyLinks plans the changes for every project in the links file before making any, so a project that is not found changes nothing
+func applyLinks(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, linksFile *LinksFile, dryRun bool) (*LinkApplyResponse, *ProjectError) {
+	projects, projErr := GetAll(httpClient, conInfo, conURL)
+	if projErr != nil {
+		return nil, projErr
+	}
+	projectIDs := map[string]string{}
+	projectNames := map[string]string{}
+	for _, project := range projects {
+		projectIDs[project.Name] = project.ProjectID
+		projectNames[project.ProjectID] = project.Name
+	}
+
+	response := &LinkApplyResponse{ConnectionID: conInfo.ID, DryRun: dryRun, Changes: []LinkChange{}}
+	for _, project := range linksFile.Projects {
+		projectID, found := projectIDs[project.Name]
+		if !found {
+			err := fmt.Errorf("no project named %v is bound to connection %v", project.Name, conInfo.ID)
+			return nil, &ProjectError{errOpNotFound, err, err.Error()}
+		}
+		desired := []Link{}
+		for _, link := range project.Links {
+			targetID, found := projectIDs[link.Target]
+			if !found {
+				err := fmt.Errorf("project %v links to %v, but no project named %v is bound to connection %v", project.Name, link.Target, link.Target, conInfo.ID)
+				return nil, &ProjectError{errOpNotFound, err, err.Error()}
+			}
+			desired = append(desired, Link{ProjectID: targetID, EnvName: link.Env})
+		}
+		current, projErr := GetProjectLinks(httpClient, conInfo, conURL, projectID)
+		if projErr != nil {
+			return nil, projErr
+		}
+
+		changes, unchanged := PlanLinkChanges(current, desired)
+		for i := range changes {
+			changes[i].ProjectName = project.Name
+			changes[i].ProjectID = projectID
+			changes[i].TargetName = projectNames[changes[i].TargetProjectID]
+		}
+		response.Changes = append(response.Changes, changes...)
+		response.Unchanged += unchanged
+	}
+
+	if dryRun {
+		return response, nil
+	}
+	for i := range response.Changes {
+		change := &response.Changes[i]
+		if projErr := applyLinkChange(httpClient, conInfo, conURL, *change); projErr != nil {
+			change.Status, change.Error = LinkChangeFailed, projErr.Desc
+			response.Failed++
+			continue
+		}
+		change.Status = LinkChangeApplied
+	}
+	if response.Failed > 0 {
+		err := fmt.Errorf("%v of %v link changes failed", response.Failed, len(response.Changes))
+		return response, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return response, nil
+}
+
+// PlanLinkChanges : The changes that turn a project's current links into the desired ones, and how many links already match.
+// A link whose target is kept but whose env changes is renamed, and links are removed first, then renamed, then created.
+// A link is only renamed onto an env after the link holding it has been renamed away, and where renames form a cycle,
+// such as two links swapping envs, one link of the cycle is removed and created again instead, so every env is free by the time it is needed.
+func PlanLinkChanges(current []Link, desired []Link) ([]LinkChange, int) {
+	unchanged := 0
+	toRemove := []Link{}
+	toCreate := []Link{}
+	for _, link := range current {
+		if !containsLink(desired, link) {
+			toRemove = append(toRemove, link)
+		}
+	}
+	for _, link := range desired {
+		if containsLink(current, link) {
+			unchanged++
+		} else {
+			toCreate = append(toCreate, link)
+		}
+	}
+
+	renames := []LinkChange{}
+	for i := 0; i < len(toCreate); i++ {
+		for j, old := range toRemove {
+			if old.ProjectID == toCreate[i].ProjectID {
+				renames = append(renames, LinkChange{Action: LinkActionRename, EnvName: old.EnvName, NewEnvName: toCreate[i].EnvName, TargetProjectID: old.ProjectID, Status: LinkChangePlanned})
+				toRemove = append(toRemove[:j], toRemove[j+1:]...)
+				toCreate = append(toCreate[:i], toCreate[i+1:]...)
+				i--
+				break
+			}
+		}
+	}
+
+	renames, toRemove, toCreate = orderRenames(renames, toRemove, toCreate)
+
+	changes := []LinkChange{}
+	for _, link := range toRemove {
+		changes = append(changes, LinkChange{Action: LinkActionRemove, EnvName: link.EnvName, TargetProjectID: link.ProjectID, Status: LinkChangePlanned})
+	}
+	changes = append(changes, renames...)
+	for _, link := range toCreate {
+		changes = append(changes, LinkChange{Action: LinkActionCreate, EnvName: link.EnvName, TargetProjectID: link.ProjectID, Status: LinkChangePlanned})
+	}
+	return changes, unchanged
+}
+
+// orderRenames orders the renames so none is onto an env still held by a link renamed after it.
+// The renames left when none can go next form cycles, each broken by removing one of its links and creating it again with its new env.
+func orderRenames(renames []LinkChange, toRemove []Link, toCreate []Link) ([]LinkChange, []Link, []Link) {
+	ordered := []LinkChange{}
+	for len(renames) > 0 {
+		blocked := []LinkChange{}
+		for _, rename := range renames {
+			if findRenameFrom(renames, rename.NewEnvName) != nil {
+				blocked = append(blocked, rename)
+			} else {
+				ordered = append(ordered, rename)
+			}
+		}
+		if len(blocked) == len(renames) {
+			// no two links are renamed onto the same env, so every blocked rename is part of a cycle
+			broken := blocked[0]
+			toRemove = append(toRemove, Link{ProjectID: broken.TargetProjectID, EnvName: broken.EnvName})
+			toCreate = append(toCreate, Link{ProjectID: broken.TargetProjectID, EnvName: broken.NewEnvName})
+			blocked = blocked[1:]
+		}
+		renames = blocked
+	}
+	return ordered, toRemove, toCreate
+}
+
+// findRenameFrom returns the rename of the link with the env, or nil if it is not renamed
+func findRenameFrom(renames []LinkChange, envName string) *LinkChange {
+	for i := range renames {
+		if renames[i].EnvName == envName {
+			return &renames[i]
+		}
+	}
+	return nil
+}
+
+// applyLinkChange makes one link change on PFE
+func applyLinkChange(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, change LinkChange) *ProjectError {
+	switch change.Action {
+	case LinkActionCreate:
+		return CreateProjectLink(httpClient, conInfo, conURL, change.ProjectID, change.TargetProjectID, change.EnvName)
+	case LinkActionRename:
+		return UpdateProjectLink(httpClient, conInfo, conURL, change.ProjectID, change.EnvName, change.NewEnvName)
+	}
+	return DeleteProjectLink(httpClient, conInfo, conURL, change.ProjectID, change.EnvName)
+}
+
+// containsLink returns true if one of the links has the same env and target as the link
+func containsLink(links []Link, link Link) bool {
+	for _, candidate := range links {
+		if candidate.EnvName == link.EnvName && candidate.ProjectID == link.ProjectID {
+			return true
+		}
+	}
+	return false
+}
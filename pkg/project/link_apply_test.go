@@ -0,0 +1,179 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanLinkChanges(t *testing.T) {
+	tests := map[string]struct {
+		current       []Link
+		desired       []Link
+		wantChanges   []LinkChange
+		wantUnchanged int
+	}{
+		"already converged": {
+			current:       []Link{{ProjectID: "api-id", EnvName: "API_URL"}},
+			desired:       []Link{{ProjectID: "api-id", EnvName: "API_URL"}},
+			wantChanges:   []LinkChange{},
+			wantUnchanged: 1,
+		},
+		"create and remove": {
+			current: []Link{{ProjectID: "old-id", EnvName: "OLD_URL"}},
+			desired: []Link{{ProjectID: "api-id", EnvName: "API_URL"}},
+			wantChanges: []LinkChange{
+				{Action: LinkActionRemove, EnvName: "OLD_URL", TargetProjectID: "old-id", Status: LinkChangePlanned},
+				{Action: LinkActionCreate, EnvName: "API_URL", TargetProjectID: "api-id", Status: LinkChangePlanned},
+			},
+		},
+		"same target with a new env is renamed": {
+			current:     []Link{{ProjectID: "api-id", EnvName: "API"}},
+			desired:     []Link{{ProjectID: "api-id", EnvName: "API_URL"}},
+			wantChanges: []LinkChange{{Action: LinkActionRename, EnvName: "API", NewEnvName: "API_URL", TargetProjectID: "api-id", Status: LinkChangePlanned}},
+		},
+		"an env moved to another target is removed before it is created": {
+			current: []Link{{ProjectID: "old-id", EnvName: "DB_URL"}, {ProjectID: "auth-id", EnvName: "AUTH_URL"}},
+			desired: []Link{{ProjectID: "db-id", EnvName: "DB_URL"}, {ProjectID: "auth-id", EnvName: "AUTH_URL"}},
+			wantChanges: []LinkChange{
+				{Action: LinkActionRemove, EnvName: "DB_URL", TargetProjectID: "old-id", Status: LinkChangePlanned},
+				{Action: LinkActionCreate, EnvName: "DB_URL", TargetProjectID: "db-id", Status: LinkChangePlanned},
+			},
+			wantUnchanged: 1,
+		},
+		"a link is renamed onto an env once the link holding it has been renamed away": {
+			current: []Link{{ProjectID: "api-id", EnvName: "API"}, {ProjectID: "auth-id", EnvName: "API_URL"}},
+			desired: []Link{{ProjectID: "api-id", EnvName: "API_URL"}, {ProjectID: "auth-id", EnvName: "AUTH_URL"}},
+			wantChanges: []LinkChange{
+				{Action: LinkActionRename, EnvName: "API_URL", NewEnvName: "AUTH_URL", TargetProjectID: "auth-id", Status: LinkChangePlanned},
+				{Action: LinkActionRename, EnvName: "API", NewEnvName: "API_URL", TargetProjectID: "api-id", Status: LinkChangePlanned},
+			},
+		},
+		"links that swap envs are a remove, a rename and a create": {
+			current: []Link{{ProjectID: "api-id", EnvName: "API_URL"}, {ProjectID: "auth-id", EnvName: "AUTH_URL"}},
+			desired: []Link{{ProjectID: "api-id", EnvName: "AUTH_URL"}, {ProjectID: "auth-id", EnvName: "API_URL"}},
+			wantChanges: []LinkChange{
+				{Action: LinkActionRemove, EnvName: "API_URL", TargetProjectID: "api-id", Status: LinkChangePlanned},
+				{Action: LinkActionRename, EnvName: "AUTH_URL", NewEnvName: "API_URL", TargetProjectID: "auth-id", Status: LinkChangePlanned},
+				{Action: LinkActionCreate, EnvName: "AUTH_URL", TargetProjectID: "api-id", Status: LinkChangePlanned},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			changes, unchanged := PlanLinkChanges(test.current, test.desired)
+			assert.Equal(t, test.wantChanges, changes)
+			assert.Equal(t, test.wantUnchanged, unchanged)
+		})
+	}
+}
+
+func TestReadLinksFile(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "link_apply_test")
+	defer os.RemoveAll(testDir)
+	linksFilePath := filepath.Join(testDir, "links.yaml")
+
+	t.Run("success case - projects and their links", func(t *testing.T) {
+		ioutil.WriteFile(linksFilePath, []byte("projects:\n  - name: web\n    links:\n      - env: API_URL\n        target: api\n  - name: api\n"), 0644)
+		linksFile, err := ReadLinksFile(linksFilePath)
+		assert.Nil(t, err)
+		assert.Equal(t, &LinksFile{Projects: []LinksFileProject{
+			{Name: "web", Links: []LinksFileLink{{Env: "API_URL", Target: "api"}}},
+			{Name: "api"},
+		}}, linksFile)
+	})
+
+	tests := map[string]string{
+		"an unknown field":       "projects:\n  - name: web\n    link:\n      - env: API_URL\n        target: api\n",
+		"a project twice":        "projects:\n  - name: web\n  - name: web\n",
+		"a project with no name": "projects:\n  - links: []\n",
+		"a link with no target":  "projects:\n  - name: web\n    links:\n      - env: API_URL\n",
+		"an env twice":           "projects:\n  - name: web\n    links:\n      - {env: API_URL, target: api}\n      - {env: API_URL, target: auth}\n",
+	}
+	for name, content := range tests {
+		t.Run("fail case - "+name, func(t *testing.T) {
+			ioutil.WriteFile(linksFilePath, []byte(content), 0644)
+			_, err := ReadLinksFile(linksFilePath)
+			assert.Equal(t, errOpFileParse, err.Op)
+		})
+	}
+}
+
+func TestApplyLinks(t *testing.T) {
+	linksFile := &LinksFile{Projects: []LinksFileProject{
+		{Name: "web", Links: []LinksFileLink{{Env: "API_URL", Target: "api"}, {Env: "AUTH_URL", Target: "auth"}}},
+		{Name: "api"},
+	}}
+	routes := func() map[string]mockPFEResponse {
+		return map[string]mockPFEResponse{
+			"GET /api/v1/projects/":                {http.StatusOK, []Project{{ProjectID: "web-id", Name: "web"}, {ProjectID: "api-id", Name: "api"}, {ProjectID: "auth-id", Name: "auth"}}},
+			"GET /api/v1/projects/web-id/links":    {http.StatusOK, []Link{{ProjectID: "api-id", EnvName: "API"}}},
+			"GET /api/v1/projects/api-id/links":    {http.StatusOK, []Link{{ProjectID: "auth-id", EnvName: "AUTH_URL"}}},
+			"PUT /api/v1/projects/web-id/links":    {http.StatusAccepted, nil},
+			"POST /api/v1/projects/web-id/links":   {http.StatusAccepted, nil},
+			"DELETE /api/v1/projects/api-id/links": {http.StatusAccepted, nil},
+		}
+	}
+
+	t.Run("success case - a dry run plans the changes without making them", func(t *testing.T) {
+		client := &clientMockPFE{routes: routes()}
+		response, err := applyLinks(client, &mockConnection, "http://pfe", linksFile, true)
+		assert.Nil(t, err)
+		assert.Equal(t, []LinkChange{
+			{Action: LinkActionRename, ProjectName: "web", ProjectID: "web-id", EnvName: "API", NewEnvName: "API_URL", TargetName: "api", TargetProjectID: "api-id", Status: LinkChangePlanned},
+			{Action: LinkActionCreate, ProjectName: "web", ProjectID: "web-id", EnvName: "AUTH_URL", TargetName: "auth", TargetProjectID: "auth-id", Status: LinkChangePlanned},
+			{Action: LinkActionRemove, ProjectName: "api", ProjectID: "api-id", EnvName: "AUTH_URL", TargetName: "auth", TargetProjectID: "auth-id", Status: LinkChangePlanned},
+		}, response.Changes)
+		for _, request := range client.requests {
+			assert.Regexp(t, "^GET ", request)
+		}
+	})
+
+	t.Run("success case - the changes are made", func(t *testing.T) {
+		client := &clientMockPFE{routes: routes()}
+		response, err := applyLinks(client, &mockConnection, "http://pfe", linksFile, false)
+		assert.Nil(t, err)
+		for _, change := range response.Changes {
+			assert.Equal(t, LinkChangeApplied, change.Status)
+		}
+		assert.Contains(t, client.requests, `PUT /api/v1/projects/web-id/links {"envName":"API","updatedEnvName":"API_URL"}`)
+		assert.Contains(t, client.requests, `POST /api/v1/projects/web-id/links {"envName":"AUTH_URL","targetProjectID":"auth-id"}`)
+		assert.Contains(t, client.requests, `DELETE /api/v1/projects/api-id/links {"envName":"AUTH_URL"}`)
+	})
+
+	t.Run("fail case - a change is rejected, and the rest are still made", func(t *testing.T) {
+		failingRoutes := routes()
+		failingRoutes["PUT /api/v1/projects/web-id/links"] = mockPFEResponse{http.StatusConflict, nil}
+		response, err := applyLinks(&clientMockPFE{routes: failingRoutes}, &mockConnection, "http://pfe", linksFile, false)
+		assert.Equal(t, errOpResponse, err.Op)
+		assert.Equal(t, 1, response.Failed)
+		assert.Equal(t, LinkChangeFailed, response.Changes[0].Status)
+		assert.Equal(t, textProjectLinkConflict, response.Changes[0].Error)
+		assert.Equal(t, LinkChangeApplied, response.Changes[2].Status)
+	})
+
+	t.Run("fail case - a target that is not bound changes nothing", func(t *testing.T) {
+		client := &clientMockPFE{routes: routes()}
+		missingTarget := &LinksFile{Projects: []LinksFileProject{{Name: "api"}, {Name: "web", Links: []LinksFileLink{{Env: "DB_URL", Target: "db"}}}}}
+		_, err := applyLinks(client, &mockConnection, "http://pfe", missingTarget, false)
+		assert.Equal(t, errOpNotFound, err.Op)
+		for _, request := range client.requests {
+			assert.Regexp(t, "^GET ", request)
+		}
+	})
+}
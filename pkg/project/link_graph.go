@@ -0,0 +1,273 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// The reasons a link is dangling
+const (
+	LinkTargetMissing = "missing"
+	LinkTargetStopped = "stopped"
+)
+
+// The formats a link graph can be written in
+const (
+	LinkGraphFormatDOT     = "dot"
+	LinkGraphFormatMermaid = "mermaid"
+	LinkGraphFormatJSON    = "json"
+)
+
+type (
+	// LinkGraphProject : A project in a link graph
+	LinkGraphProject struct {
+		ProjectID string `json:"projectID"`
+		Name      string `json:"name"`
+		AppStatus string `json:"appStatus"`
+	}
+
+	// LinkGraphEdge : A link from one project to another
+	LinkGraphEdge struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		EnvName string `json:"envName"`
+	}
+
+	// DanglingLink : A link whose target project no longer exists or is stopped
+	DanglingLink struct {
+		LinkGraphEdge
+		Reason string `json:"reason"`
+	}
+
+	// LinkGraph : The links between every project bound to a connection, as the links from each project ID
+	LinkGraph struct {
+		ConnectionID string                     `json:"connectionID"`
+		Projects     []LinkGraphProject         `json:"projects"`
+		Adjacency    map[string][]LinkGraphEdge `json:"adjacency"`
+		Dangling     []DanglingLink             `json:"dangling"`
+		Cycles       [][]string                 `json:"cycles"`
+	}
+)
+
+// GetLinkGraph : Gather the links of every project bound to a connection into a graph, finding its dangling links and cycles
+func GetLinkGraph(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string) (*LinkGraph, *ProjectError) {
+	projects, projErr := GetAll(httpClient, conInfo, conURL)
+	if projErr != nil {
+		return nil, projErr
+	}
+	links := map[string][]Link{}
+	for _, project := range projects {
+		projectLinks, projErr := GetProjectLinks(httpClient, conInfo, conURL, project.ProjectID)
+		if projErr != nil {
+			return nil, projErr
+		}
+		links[project.ProjectID] = projectLinks
+	}
+	graph := NewLinkGraph(projects, links)
+	graph.ConnectionID = conInfo.ID
+	return graph, nil
+}
+
+// NewLinkGraph : Build the graph of the given links from each project
+func NewLinkGraph(projects []Project, links map[string][]Link) *LinkGraph {
+	graph := &LinkGraph{
+		Projects:  []LinkGraphProject{},
+		Adjacency: map[string][]LinkGraphEdge{},
+		Dangling:  []DanglingLink{},
+	}
+	statuses := map[string]string{}
+	for _, project := range projects {
+		graph.Projects = append(graph.Projects, LinkGraphProject{ProjectID: project.ProjectID, Name: project.Name, AppStatus: project.AppStatus})
+		statuses[project.ProjectID] = project.AppStatus
+	}
+
+	for _, project := range projects {
+		edges := []LinkGraphEdge{}
+		for _, link := range links[project.ProjectID] {
+			edge := LinkGraphEdge{From: project.ProjectID, To: link.ProjectID, EnvName: link.EnvName}
+			edges = append(edges, edge)
+
+			status, found := statuses[link.ProjectID]
+			if !found {
+				graph.Dangling = append(graph.Dangling, DanglingLink{edge, LinkTargetMissing})
+			} else if status == "stopped" {
+				graph.Dangling = append(graph.Dangling, DanglingLink{edge, LinkTargetStopped})
+			}
+		}
+		graph.Adjacency[project.ProjectID] = edges
+	}
+	graph.Cycles = graph.findCycles()
+	return graph
+}
+
+// findCycles returns each cycle of links as the project IDs in it, starting from the first of them in the order projects were listed.
+// A cycle is found each time a link leads back to a project on the current path, so a cycle that only shares links with ones already found
+// may not be reported, but every project in a cycle is in at least one that is.
+func (graph *LinkGraph) findCycles() [][]string {
+	order := map[string]int{}
+	for i, project := range graph.Projects {
+		order[project.ProjectID] = i
+	}
+
+	cycles := [][]string{}
+	found := map[string]bool{}
+	visited := map[string]bool{}
+	onPath := map[string]int{}
+	path := []string{}
+
+	var visit func(projectID string)
+	visit = func(projectID string) {
+		visited[projectID] = true
+		onPath[projectID] = len(path)
+		path = append(path, projectID)
+		for _, edge := range graph.Adjacency[projectID] {
+			if start, isOnPath := onPath[edge.To]; isOnPath {
+				cycle := rotateCycle(append([]string{}, path[start:]...), order)
+				key := strings.Join(cycle, " ")
+				if !found[key] {
+					found[key] = true
+					cycles = append(cycles, cycle)
+				}
+			} else if _, exists := order[edge.To]; exists && !visited[edge.To] {
+				visit(edge.To)
+			}
+		}
+		path = path[:len(path)-1]
+		delete(onPath, projectID)
+	}
+	for _, project := range graph.Projects {
+		if !visited[project.ProjectID] {
+			visit(project.ProjectID)
+		}
+	}
+	return cycles
+}
+
+// rotateCycle rotates a cycle to start from the project listed first, so the same cycle is always given the same way
+func rotateCycle(cycle []string, order map[string]int) []string {
+	first := 0
+	for i := range cycle {
+		if order[cycle[i]] < order[cycle[first]] {
+			first = i
+		}
+	}
+	return append(cycle[first:], cycle[:first]...)
+}
+
+// FormatLinkGraph : Write a link graph as a Graphviz DOT or Mermaid diagram, with dangling links and missing projects drawn in red
+func FormatLinkGraph(graph *LinkGraph, format string) (string, *ProjectError) {
+	switch format {
+	case LinkGraphFormatDOT:
+		return formatLinkGraphDOT(graph), nil
+	case LinkGraphFormatMermaid:
+		return formatLinkGraphMermaid(graph), nil
+	}
+	err := fmt.Errorf("unknown format %v, the formats are %v, %v and %v", format, LinkGraphFormatDOT, LinkGraphFormatMermaid, LinkGraphFormatJSON)
+	return "", &ProjectError{errOpInvalidOptions, err, err.Error()}
+}
+
+func formatLinkGraphDOT(graph *LinkGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph links {\n")
+	for _, project := range graph.Projects {
+		fmt.Fprintf(&b, "  %q [label=%q];\n", project.ProjectID, project.Name)
+	}
+	for _, projectID := range graph.missingProjectIDs() {
+		fmt.Fprintf(&b, "  %q [label=%q, style=dashed, color=red];\n", projectID, projectID+" (missing)")
+	}
+	dangling := graph.danglingEdges()
+	for _, project := range graph.Projects {
+		for _, edge := range graph.Adjacency[project.ProjectID] {
+			attributes := fmt.Sprintf("label=%q", edge.EnvName)
+			if dangling[edge] {
+				attributes += ", color=red"
+			}
+			fmt.Fprintf(&b, "  %q -> %q [%v];\n", edge.From, edge.To, attributes)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func formatLinkGraphMermaid(graph *LinkGraph) string {
+	// project IDs are not always valid Mermaid node IDs, so each project is given one by its position
+	nodeIDs := map[string]string{}
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, project := range graph.Projects {
+		nodeIDs[project.ProjectID] = fmt.Sprintf("p%d", len(nodeIDs))
+		fmt.Fprintf(&b, "  %v[\"%v\"]\n", nodeIDs[project.ProjectID], mermaidText(project.Name))
+	}
+	for _, projectID := range graph.missingProjectIDs() {
+		nodeIDs[projectID] = fmt.Sprintf("p%d", len(nodeIDs))
+		fmt.Fprintf(&b, "  %v[\"%v (missing)\"]:::dangling\n", nodeIDs[projectID], mermaidText(projectID))
+	}
+	dangling := graph.danglingEdges()
+	edgeIndex := 0
+	danglingIndexes := []string{}
+	for _, project := range graph.Projects {
+		for _, edge := range graph.Adjacency[project.ProjectID] {
+			fmt.Fprintf(&b, "  %v -->|%v| %v\n", nodeIDs[edge.From], mermaidText(edge.EnvName), nodeIDs[edge.To])
+			if dangling[edge] {
+				danglingIndexes = append(danglingIndexes, fmt.Sprint(edgeIndex))
+			}
+			edgeIndex++
+		}
+	}
+	b.WriteString("  classDef dangling stroke:red,stroke-dasharray:5\n")
+	if len(danglingIndexes) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %v stroke:red\n", strings.Join(danglingIndexes, ","))
+	}
+	return b.String()
+}
+
+// mermaidText escapes the characters that end a Mermaid label
+func mermaidText(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;").Replace(text)
+}
+
+// missingProjectIDs returns the IDs of projects that are linked to but not bound to the connection, in the order they are first linked to
+func (graph *LinkGraph) missingProjectIDs() []string {
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, link := range graph.Dangling {
+		if link.Reason == LinkTargetMissing && !seen[link.To] {
+			seen[link.To] = true
+			missing = append(missing, link.To)
+		}
+	}
+	return missing
+}
+
+// danglingEdges returns the set of the graph's dangling links
+func (graph *LinkGraph) danglingEdges() map[LinkGraphEdge]bool {
+	edges := map[LinkGraphEdge]bool{}
+	for _, link := range graph.Dangling {
+		edges[link.LinkGraphEdge] = true
+	}
+	return edges
+}
+
+// ProjectName : The name of a project in the graph, or its ID if it is not bound to the connection
+func (graph *LinkGraph) ProjectName(projectID string) string {
+	for _, project := range graph.Projects {
+		if project.ProjectID == projectID {
+			return project.Name
+		}
+	}
+	return projectID
+}
@@ -0,0 +1,109 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testGraphProjects = []Project{
+	{ProjectID: "web-id", Name: "web", AppStatus: "started"},
+	{ProjectID: "api-id", Name: "api", AppStatus: "started"},
+	{ProjectID: "auth-id", Name: "auth", AppStatus: "stopped"},
+}
+
+var testGraphLinks = map[string][]Link{
+	"web-id":  {{ProjectID: "api-id", EnvName: "API_URL"}, {ProjectID: "gone-id", EnvName: "OLD_URL"}},
+	"api-id":  {{ProjectID: "auth-id", EnvName: "AUTH_URL"}},
+	"auth-id": {{ProjectID: "api-id", EnvName: "API_URL"}},
+}
+
+func TestNewLinkGraph(t *testing.T) {
+	t.Run("success case - dangling links and cycles are found", func(t *testing.T) {
+		graph := NewLinkGraph(testGraphProjects, testGraphLinks)
+		assert.Len(t, graph.Projects, 3)
+		assert.Equal(t, []LinkGraphEdge{{From: "web-id", To: "api-id", EnvName: "API_URL"}, {From: "web-id", To: "gone-id", EnvName: "OLD_URL"}}, graph.Adjacency["web-id"])
+		assert.Equal(t, []DanglingLink{
+			{LinkGraphEdge{From: "web-id", To: "gone-id", EnvName: "OLD_URL"}, LinkTargetMissing},
+			{LinkGraphEdge{From: "api-id", To: "auth-id", EnvName: "AUTH_URL"}, LinkTargetStopped},
+		}, graph.Dangling)
+		assert.Equal(t, [][]string{{"api-id", "auth-id"}}, graph.Cycles)
+	})
+
+	t.Run("success case - a cycle is given the same way wherever it is entered", func(t *testing.T) {
+		projects := []Project{{ProjectID: "a"}, {ProjectID: "b"}, {ProjectID: "c"}}
+		links := map[string][]Link{"a": {{ProjectID: "b"}}, "b": {{ProjectID: "c"}}, "c": {{ProjectID: "a"}, {ProjectID: "c"}}}
+		graph := NewLinkGraph(projects, links)
+		assert.Equal(t, [][]string{{"a", "b", "c"}, {"c"}}, graph.Cycles)
+		assert.Empty(t, graph.Dangling)
+	})
+
+	t.Run("success case - no links", func(t *testing.T) {
+		graph := NewLinkGraph(testGraphProjects, map[string][]Link{})
+		assert.Empty(t, graph.Cycles)
+		assert.Empty(t, graph.Dangling)
+		assert.Equal(t, []LinkGraphEdge{}, graph.Adjacency["web-id"])
+	})
+}
+
+func TestGetLinkGraph(t *testing.T) {
+	client := &clientMockPFE{routes: map[string]mockPFEResponse{
+		"GET /api/v1/projects/":              {http.StatusOK, testGraphProjects[:2]},
+		"GET /api/v1/projects/web-id/links":  {http.StatusOK, testGraphLinks["web-id"]},
+		"GET /api/v1/projects/api-id/links":  {http.StatusOK, []Link{}},
+		"GET /api/v1/projects/auth-id/links": {http.StatusOK, testGraphLinks["auth-id"]},
+	}}
+	graph, err := GetLinkGraph(client, &mockConnection, "http://pfe")
+	assert.Nil(t, err)
+	assert.Equal(t, "local", graph.ConnectionID)
+	assert.Len(t, graph.Adjacency["web-id"], 2)
+	assert.Equal(t, []DanglingLink{{LinkGraphEdge{From: "web-id", To: "gone-id", EnvName: "OLD_URL"}, LinkTargetMissing}}, graph.Dangling)
+}
+
+func TestFormatLinkGraph(t *testing.T) {
+	graph := NewLinkGraph(testGraphProjects[:2], map[string][]Link{"web-id": testGraphLinks["web-id"]})
+
+	t.Run("success case - DOT", func(t *testing.T) {
+		dot, err := FormatLinkGraph(graph, LinkGraphFormatDOT)
+		assert.Nil(t, err)
+		assert.Equal(t, `digraph links {
+  "web-id" [label="web"];
+  "api-id" [label="api"];
+  "gone-id" [label="gone-id (missing)", style=dashed, color=red];
+  "web-id" -> "api-id" [label="API_URL"];
+  "web-id" -> "gone-id" [label="OLD_URL", color=red];
+}
+`, dot)
+	})
+
+	t.Run("success case - Mermaid", func(t *testing.T) {
+		mermaid, err := FormatLinkGraph(graph, LinkGraphFormatMermaid)
+		assert.Nil(t, err)
+		assert.Equal(t, `graph LR
+  p0["web"]
+  p1["api"]
+  p2["gone-id (missing)"]:::dangling
+  p0 -->|API_URL| p1
+  p0 -->|OLD_URL| p2
+  classDef dangling stroke:red,stroke-dasharray:5
+  linkStyle 1 stroke:red
+`, mermaid)
+	})
+
+	t.Run("fail case - an unknown format", func(t *testing.T) {
+		_, err := FormatLinkGraph(graph, "svg")
+		assert.Equal(t, errOpInvalidOptions, err.Op)
+	})
+}
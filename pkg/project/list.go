@@ -0,0 +1,264 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+type (
+	// ListedProject : A project and the connection it is bound to
+	ListedProject struct {
+		Project
+		ConnectionID string `json:"connectionID"`
+	}
+
+	// UnreachableConnection : A connection whose projects could not be listed
+	UnreachableConnection struct {
+		ConnectionID string `json:"connectionID"`
+		Error        string `json:"error"`
+	}
+
+	// ListResponse : The projects that matched the filters, and the connections that could not be listed
+	ListResponse struct {
+		Projects    []ListedProject         `json:"projects"`
+		Unreachable []UnreachableConnection `json:"unreachable"`
+	}
+
+	// ListOptions : How to filter and sort a list of projects
+	ListOptions struct {
+		Statuses  []string // only list projects with one of these app statuses
+		Languages []string // only list projects with one of these languages
+		Name      string   // only list projects whose name matches this glob pattern
+		SortBy    string   // the column to sort by
+		Reverse   bool     // sort from last to first
+	}
+
+	// listColumn is a column that projects can be listed with, filtered or sorted by
+	listColumn struct {
+		header string
+		value  func(ListedProject) string
+	}
+)
+
+// ListColumnNames are the columns a list of projects can show, in the order they are described
+var ListColumnNames = []string{"id", "name", "language", "type", "status", "host", "location", "connection"}
+
+// DefaultListColumns are the columns shown when none are chosen
+var DefaultListColumns = []string{"id", "name", "language", "status", "location"}
+
+var listColumns = map[string]listColumn{
+	"id":         {"PROJECT ID", func(p ListedProject) string { return p.ProjectID }},
+	"name":       {"NAME", func(p ListedProject) string { return p.Name }},
+	"language":   {"LANGUAGE", func(p ListedProject) string { return p.Language }},
+	"type":       {"TYPE", func(p ListedProject) string { return p.ProjectType }},
+	"status":     {"APP STATUS", func(p ListedProject) string { return strings.Title(p.AppStatus) }},
+	"host":       {"HOST", func(p ListedProject) string { return p.Host }},
+	"location":   {"LOCATION ON DISK", func(p ListedProject) string { return p.LocationOnDisk }},
+	"connection": {"CONNECTION", func(p ListedProject) string { return p.ConnectionID }},
+}
+
+// ListProjects : List the projects bound to the connection given by --conid, or to every connection if --all-connections is given,
+// keeping those that match the filters. Connections that cannot be reached are only an error when a single connection is listed.
+func ListProjects(c *cli.Context) (*ListResponse, *ProjectError) {
+	options := ListOptions{
+		Statuses:  splitListFilter(c.String("status")),
+		Languages: splitListFilter(c.String("language")),
+		Name:      strings.TrimSpace(c.String("name")),
+		SortBy:    strings.TrimSpace(strings.ToLower(c.String("sort"))),
+		Reverse:   c.Bool("reverse"),
+	}
+	projErr := options.validate()
+	if projErr != nil {
+		return nil, projErr
+	}
+
+	response := &ListResponse{Projects: []ListedProject{}, Unreachable: []UnreachableConnection{}}
+	if !c.Bool("all-connections") {
+		conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+		conInfo, conURL, projErr := GetConnectionAndURL(conID)
+		if projErr != nil {
+			return nil, projErr
+		}
+		projects, projErr := listConnectionProjects(http.DefaultClient, conInfo, conURL)
+		if projErr != nil {
+			return nil, projErr
+		}
+		response.Projects = projects
+	} else {
+		allConnections, conErr := connections.GetConnectionsConfig()
+		if conErr != nil {
+			return nil, &ProjectError{errOpConNotFound, conErr, conErr.Error()}
+		}
+		conInfos := []*connections.Connection{}
+		conURLs := []string{}
+		for _, connection := range allConnections.Connections {
+			conInfo, conURL, projErr := GetConnectionAndURL(connection.ID)
+			if projErr != nil {
+				response.Unreachable = append(response.Unreachable, UnreachableConnection{ConnectionID: connection.ID, Error: projErr.Desc})
+				continue
+			}
+			conInfos = append(conInfos, conInfo)
+			conURLs = append(conURLs, conURL)
+		}
+		projects, unreachable := listAllConnectionProjects(http.DefaultClient, conInfos, conURLs)
+		response.Projects = projects
+		response.Unreachable = append(response.Unreachable, unreachable...)
+	}
+
+	response.Projects = FilterProjects(response.Projects, options)
+	SortProjects(response.Projects, options)
+	return response, nil
+}
+
+// listAllConnectionProjects lists the projects of each connection at the same time, in the order the connections are given
+func listAllConnectionProjects(httpClient utils.HTTPClient, conInfos []*connections.Connection, conURLs []string) ([]ListedProject, []UnreachableConnection) {
+	projects := make([][]ListedProject, len(conInfos))
+	errs := make([]*ProjectError, len(conInfos))
+	utils.RunInParallel(len(conInfos), len(conInfos), func(i int) {
+		projects[i], errs[i] = listConnectionProjects(httpClient, conInfos[i], conURLs[i])
+	})
+
+	allProjects := []ListedProject{}
+	unreachable := []UnreachableConnection{}
+	for i, conInfo := range conInfos {
+		if errs[i] != nil {
+			unreachable = append(unreachable, UnreachableConnection{ConnectionID: conInfo.ID, Error: errs[i].Desc})
+			continue
+		}
+		allProjects = append(allProjects, projects[i]...)
+	}
+	return allProjects, unreachable
+}
+
+// listConnectionProjects lists the projects bound to one connection
+func listConnectionProjects(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string) ([]ListedProject, *ProjectError) {
+	projects, projErr := GetAll(httpClient, conInfo, conURL)
+	if projErr != nil {
+		return nil, projErr
+	}
+	listed := []ListedProject{}
+	for _, project := range projects {
+		listed = append(listed, ListedProject{Project: project, ConnectionID: conInfo.ID})
+	}
+	return listed, nil
+}
+
+// FilterProjects : Keep the projects that match every filter that was given
+func FilterProjects(projects []ListedProject, options ListOptions) []ListedProject {
+	filtered := []ListedProject{}
+	for _, project := range projects {
+		if len(options.Statuses) > 0 && !containsFold(options.Statuses, project.AppStatus) {
+			continue
+		}
+		if len(options.Languages) > 0 && !containsFold(options.Languages, project.Language) {
+			continue
+		}
+		if options.Name != "" {
+			// the pattern has already been validated, so an error cannot be returned
+			if matched, _ := path.Match(options.Name, project.Name); !matched {
+				continue
+			}
+		}
+		filtered = append(filtered, project)
+	}
+	return filtered
+}
+
+// SortProjects : Sort projects by a column, leaving them in the order they were listed if no column is given
+func SortProjects(projects []ListedProject, options ListOptions) {
+	column, found := listColumns[options.SortBy]
+	if !found {
+		return
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		if options.Reverse {
+			i, j = j, i
+		}
+		return strings.ToLower(column.value(projects[i])) < strings.ToLower(column.value(projects[j]))
+	})
+}
+
+// ProjectListTable : The headers and rows of a table showing the given columns of each project
+func ProjectListTable(projects []ListedProject, columns []string) ([]string, [][]string, *ProjectError) {
+	projErr := checkListColumns(columns)
+	if projErr != nil {
+		return nil, nil, projErr
+	}
+	headers := []string{}
+	for _, name := range columns {
+		headers = append(headers, listColumns[name].header)
+	}
+	rows := [][]string{}
+	for _, project := range projects {
+		row := []string{}
+		for _, name := range columns {
+			row = append(row, listColumns[name].value(project))
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows, nil
+}
+
+// validate checks the sort column and name pattern are valid
+func (options ListOptions) validate() *ProjectError {
+	if options.SortBy != "" {
+		if projErr := checkListColumns([]string{options.SortBy}); projErr != nil {
+			return projErr
+		}
+	}
+	if _, err := path.Match(options.Name, ""); err != nil {
+		err = fmt.Errorf("the name pattern %v is invalid: %v", options.Name, err)
+		return &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+	return nil
+}
+
+// checkListColumns returns an error naming the first column that projects cannot be listed with
+func checkListColumns(columns []string) *ProjectError {
+	for _, name := range columns {
+		if _, found := listColumns[name]; !found {
+			err := fmt.Errorf("unknown column %v, the columns are %v", name, strings.Join(ListColumnNames, ", "))
+			return &ProjectError{errOpInvalidOptions, err, err.Error()}
+		}
+	}
+	return nil
+}
+
+// splitListFilter splits a comma separated filter into its values
+func splitListFilter(filter string) []string {
+	values := []string{}
+	for _, value := range strings.Split(filter, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// containsFold returns true if value is one of values, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
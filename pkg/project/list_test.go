@@ -0,0 +1,121 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"flag"
+	"net/http"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+var testListedProjects = []ListedProject{
+	{Project{ProjectID: "1", Name: "nodeapp", Language: "javascript", ProjectType: "nodejs", AppStatus: "started"}, "local"},
+	{Project{ProjectID: "2", Name: "Javaapp", Language: "java", ProjectType: "liberty", AppStatus: "stopped"}, "local"},
+	{Project{ProjectID: "3", Name: "nodeworker", Language: "javascript", ProjectType: "nodejs", AppStatus: "stopped"}, "remote"},
+}
+
+func listedIDs(projects []ListedProject) []string {
+	ids := []string{}
+	for _, project := range projects {
+		ids = append(ids, project.ProjectID)
+	}
+	return ids
+}
+
+func TestFilterProjects(t *testing.T) {
+	tests := map[string]struct {
+		options ListOptions
+		wantIDs []string
+	}{
+		"no filters":              {options: ListOptions{}, wantIDs: []string{"1", "2", "3"}},
+		"status ignores case":     {options: ListOptions{Statuses: []string{"Stopped"}}, wantIDs: []string{"2", "3"}},
+		"one of many languages":   {options: ListOptions{Languages: []string{"go", "java"}}, wantIDs: []string{"2"}},
+		"name glob":               {options: ListOptions{Name: "node*"}, wantIDs: []string{"1", "3"}},
+		"every filter must match": {options: ListOptions{Name: "node*", Statuses: []string{"stopped"}}, wantIDs: []string{"3"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.wantIDs, listedIDs(FilterProjects(testListedProjects, test.options)))
+		})
+	}
+}
+
+func TestSortProjects(t *testing.T) {
+	tests := map[string]struct {
+		options ListOptions
+		wantIDs []string
+	}{
+		"unsorted":                   {options: ListOptions{}, wantIDs: []string{"1", "2", "3"}},
+		"by name, ignoring case":     {options: ListOptions{SortBy: "name"}, wantIDs: []string{"2", "1", "3"}},
+		"by status, keeping order":   {options: ListOptions{SortBy: "status"}, wantIDs: []string{"1", "2", "3"}},
+		"by connection, in reverse":  {options: ListOptions{SortBy: "connection", Reverse: true}, wantIDs: []string{"3", "1", "2"}},
+		"by language, then in order": {options: ListOptions{SortBy: "language"}, wantIDs: []string{"2", "1", "3"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			projects := append([]ListedProject{}, testListedProjects...)
+			SortProjects(projects, test.options)
+			assert.Equal(t, test.wantIDs, listedIDs(projects))
+		})
+	}
+}
+
+func TestProjectListTable(t *testing.T) {
+	t.Run("success case - the chosen columns", func(t *testing.T) {
+		headers, rows, err := ProjectListTable(testListedProjects[:2], []string{"connection", "name", "status"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"CONNECTION", "NAME", "APP STATUS"}, headers)
+		assert.Equal(t, [][]string{{"local", "nodeapp", "Started"}, {"local", "Javaapp", "Stopped"}}, rows)
+	})
+	t.Run("fail case - an unknown column", func(t *testing.T) {
+		_, _, err := ProjectListTable(testListedProjects, []string{"name", "colour"})
+		assert.Equal(t, errOpInvalidOptions, err.Op)
+		assert.Contains(t, err.Desc, "unknown column colour")
+	})
+}
+
+func TestListAllConnectionProjects(t *testing.T) {
+	// the connections are local so requests to them are not authenticated, and are told apart by their URLs
+	conInfos := []*connections.Connection{{ID: "local"}, {ID: "local"}, {ID: "local"}}
+	conURLs := []string{"http://first", "http://down", "http://second"}
+	client := &clientMockPFE{routes: map[string]mockPFEResponse{
+		"GET first/api/v1/projects/":  {http.StatusOK, []Project{testListedProjects[0].Project, testListedProjects[1].Project}},
+		"GET down/api/v1/projects/":   {http.StatusBadGateway, "Bad Gateway"},
+		"GET second/api/v1/projects/": {http.StatusOK, []Project{testListedProjects[2].Project}},
+	}}
+
+	t.Run("success case - the projects of every connection in order, reporting those that cannot be reached", func(t *testing.T) {
+		projects, unreachable := listAllConnectionProjects(client, conInfos, conURLs)
+		assert.Equal(t, []string{"1", "2", "3"}, listedIDs(projects))
+		assert.Len(t, unreachable, 1)
+		assert.NotEmpty(t, unreachable[0].Error)
+	})
+
+	t.Run("fail case - one connection that cannot be reached", func(t *testing.T) {
+		_, err := listConnectionProjects(client, conInfos[1], conURLs[1])
+		assert.NotNil(t, err)
+	})
+}
+
+func TestListProjects(t *testing.T) {
+	t.Run("fail case - an unknown sort column", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.Bool("all-connections", true, "")
+		set.String("sort", "colour", "")
+		_, err := ListProjects(cli.NewContext(nil, set, nil))
+		assert.Equal(t, errOpInvalidOptions, err.Op)
+	})
+}
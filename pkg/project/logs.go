@@ -0,0 +1,343 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/docker"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// The types of log a project has
+const (
+	LogTypeBuild = "build"
+	LogTypeApp   = "app"
+)
+
+type (
+	// LogLine : One line of a project's log
+	LogLine struct {
+		Time    string `json:"time"`
+		Type    string `json:"type"`
+		Log     string `json:"log"`
+		Message string `json:"message"`
+	}
+
+	// ProjectLog : A log of a project, as listed by PFE
+	ProjectLog struct {
+		LogName          string   `json:"logName"`
+		WorkspaceLogPath string   `json:"workspaceLogPath,omitempty"`
+		Files            []string `json:"files,omitempty"`
+	}
+
+	// ProjectLogs : The build and app logs of a project, as listed by PFE
+	ProjectLogs struct {
+		Build []ProjectLog `json:"build"`
+		App   []ProjectLog `json:"app"`
+	}
+)
+
+// logPollInterval is how often the logs served by PFE are read again when following them
+var logPollInterval = 2 * time.Second
+
+// StreamProjectLogs : Call onLine with each line of the build and app logs of the project given by --id, or only those
+// given by --build or --app. App logs of local projects are read from the project's container, and all other logs from PFE.
+// With --follow, new lines are passed to onLine until the process is stopped.
+func StreamProjectLogs(c *cli.Context, onLine func(LogLine)) *ProjectError {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	var projErr *ProjectError
+	if conID == "" {
+		conID, projErr = GetConnectionID(projectID)
+		if projErr != nil {
+			return projErr
+		}
+	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return projErr
+	}
+
+	logTypes := []string{}
+	if c.Bool("build") || !c.Bool("app") {
+		logTypes = append(logTypes, LogTypeBuild)
+	}
+	appLogs := c.Bool("app") || !c.Bool("build")
+	if appLogs {
+		logTypes = append(logTypes, LogTypeApp)
+	}
+
+	// only the app logs of local projects are read from Docker
+	var dockerClient docker.DockerClient
+	if appLogs && conInfo.ID == "local" {
+		var dockerErr *docker.DockerError
+		dockerClient, dockerErr = docker.NewDockerClient()
+		if dockerErr != nil {
+			return &ProjectError{errOpGetProject, dockerErr, dockerErr.Desc}
+		}
+	}
+	return streamProjectLogs(http.DefaultClient, dockerClient, conInfo, conURL, projectID, logTypes, c.Bool("follow"), onLine)
+}
+
+// streamProjectLogs reads each type of log in turn, or all of them at the same time when following them
+func streamProjectLogs(httpClient utils.HTTPClient, dockerClient docker.DockerClient, conInfo *connections.Connection, conURL string, projectID string, logTypes []string, follow bool, onLine func(LogLine)) *ProjectError {
+	pfeLogs := newPFELogReader(httpClient, conInfo, conURL, projectID)
+	readContainer := false
+	pfeLogTypes := []string{}
+	for _, logType := range logTypes {
+		if logType == LogTypeApp && conInfo.ID == "local" {
+			readContainer = true
+			continue
+		}
+		pfeLogTypes = append(pfeLogTypes, logType)
+	}
+
+	if !follow {
+		for _, logType := range logTypes {
+			var projErr *ProjectError
+			if logType == LogTypeApp && readContainer {
+				projErr = readContainerLogs(dockerClient, projectID, false, onLine)
+			} else {
+				projErr = pfeLogs.readLogs([]string{logType}, false, onLine)
+			}
+			if projErr != nil {
+				return projErr
+			}
+		}
+		return nil
+	}
+
+	// lines from the logs being followed arrive in any order, so pass them on one at a time
+	var mutex sync.Mutex
+	lockedOnLine := func(line LogLine) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		onLine(line)
+	}
+	errs := make(chan *ProjectError, 2)
+	readers := 0
+	if readContainer {
+		readers++
+		go func() {
+			errs <- readContainerLogs(dockerClient, projectID, true, lockedOnLine)
+		}()
+	}
+	if len(pfeLogTypes) > 0 {
+		readers++
+		go func() {
+			errs <- pfeLogs.followLogs(pfeLogTypes, lockedOnLine)
+		}()
+	}
+	for i := 0; i < readers; i++ {
+		if projErr := <-errs; projErr != nil {
+			return projErr
+		}
+	}
+	return nil
+}
+
+// GetProjectLogs : List the build and app logs of a project
+func GetProjectLogs(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string) (*ProjectLogs, *ProjectError) {
+	body, projErr := getProjectLogsResource(httpClient, conInfo, conURL+"/api/v1/projects/"+projectID+"/logs")
+	if projErr != nil {
+		return nil, projErr
+	}
+	var logs ProjectLogs
+	err := json.Unmarshal(body, &logs)
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return &logs, nil
+}
+
+// pfeLogReader reads the logs PFE serves for a project, remembering how many lines of each have been passed on
+type pfeLogReader struct {
+	httpClient utils.HTTPClient
+	conInfo    *connections.Connection
+	conURL     string
+	projectID  string
+	linesRead  map[string]int
+}
+
+func newPFELogReader(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string) *pfeLogReader {
+	return &pfeLogReader{httpClient, conInfo, conURL, projectID, map[string]int{}}
+}
+
+// followLogs reads the logs of the given types again every logPollInterval until one cannot be read.
+// The logs are listed again each time, so logs created while following, such as those of a first build, are followed too.
+func (r *pfeLogReader) followLogs(logTypes []string, onLine func(LogLine)) *ProjectError {
+	for {
+		if projErr := r.readLogs(logTypes, true, onLine); projErr != nil {
+			return projErr
+		}
+		time.Sleep(logPollInterval)
+	}
+}
+
+// readLogs lists the project's logs of the given types and passes each of their lines not read before to onLine.
+// When following, a request that fails in a way that may not happen again is left to be tried on the next poll.
+func (r *pfeLogReader) readLogs(logTypes []string, follow bool, onLine func(LogLine)) *ProjectError {
+	logs, projErr := GetProjectLogs(r.httpClient, r.conInfo, r.conURL, r.projectID)
+	if projErr != nil {
+		if follow && isTransientLogError(projErr) {
+			logr.Warnf("Unable to list the logs of project %v, trying again: %v", r.projectID, projErr.Desc)
+			return nil
+		}
+		return projErr
+	}
+	for _, logType := range logTypes {
+		projectLogs := logs.Build
+		if logType == LogTypeApp {
+			projectLogs = logs.App
+		}
+		for _, projectLog := range projectLogs {
+			projErr := r.readLog(logType, projectLog.LogName, onLine)
+			if projErr == nil {
+				continue
+			}
+			// a log listed a moment ago may have been removed since, such as by a new build
+			if follow && (isTransientLogError(projErr) || projErr.Op == errOpNotFound) {
+				logr.Warnf("Unable to read the %v log %v of project %v, trying again: %v", logType, projectLog.LogName, r.projectID, projErr.Desc)
+				continue
+			}
+			return projErr
+		}
+	}
+	return nil
+}
+
+// readLog passes each line of a log served by PFE that has not been read before to onLine
+func (r *pfeLogReader) readLog(logType string, logName string, onLine func(LogLine)) *ProjectError {
+	logURL := r.conURL + "/api/v1/projects/" + r.projectID + "/logs/" + logType + "/" + url.PathEscape(logName)
+	body, projErr := getProjectLogsResource(r.httpClient, r.conInfo, logURL)
+	if projErr != nil {
+		return projErr
+	}
+	// PFE does not timestamp each line, so lines are given the time they were read
+	readAt := time.Now().UTC().Format(time.RFC3339Nano)
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if len(body) == 0 {
+		lines = []string{}
+	}
+	key := logType + "/" + logName
+	linesRead := r.linesRead[key]
+	// a log that is shorter than before has been restarted, such as by a new build
+	if len(lines) < linesRead {
+		linesRead = 0
+	}
+	for _, line := range lines[linesRead:] {
+		onLine(LogLine{Time: readAt, Type: logType, Log: logName, Message: strings.TrimSuffix(line, "\r")})
+	}
+	r.linesRead[key] = len(lines)
+	return nil
+}
+
+// logsStatusError is the error for a logs request that PFE answered with an unexpected status
+type logsStatusError struct {
+	statusCode int
+}
+
+func (e *logsStatusError) Error() string {
+	return fmt.Sprintf("unable to read the logs: %v", http.StatusText(e.statusCode))
+}
+
+// isTransientLogError returns true if a logs request failed because PFE could not be reached or had a server error
+func isTransientLogError(projErr *ProjectError) bool {
+	switch err := projErr.Err.(type) {
+	case *sechttp.HTTPSecError:
+		return shouldRetry(0, err)
+	case *logsStatusError:
+		return shouldRetry(err.statusCode, nil)
+	}
+	return false
+}
+
+// getProjectLogsResource GETs a logs resource from PFE
+func getProjectLogsResource(httpClient utils.HTTPClient, conInfo *connections.Connection, resourceURL string) ([]byte, *ProjectError) {
+	req, err := http.NewRequest("GET", resourceURL, nil)
+	if err != nil {
+		return nil, &ProjectError{errOpRequest, err, err.Error()}
+	}
+	resp, httpSecError := sechttp.DispatchHTTPRequest(httpClient, req, conInfo)
+	if httpSecError != nil {
+		return nil, &ProjectError{errOpRequest, httpSecError, httpSecError.Desc}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		err := errors.New(textAPINotFound)
+		return nil, &ProjectError{errOpNotFound, err, textAPINotFound}
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := &logsStatusError{resp.StatusCode}
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return body, nil
+}
+
+// readContainerLogs passes each line of the log of a local project's container to onLine, with the time Docker recorded for it
+func readContainerLogs(dockerClient docker.DockerClient, projectID string, follow bool, onLine func(LogLine)) *ProjectError {
+	containers, dockerErr := docker.GetContainerList(dockerClient)
+	if dockerErr != nil {
+		return &ProjectError{errOpGetProject, dockerErr, dockerErr.Desc}
+	}
+
+	// project containers are named cw-<project name>-<project ID>
+	containerID, containerName := "", ""
+	for _, container := range docker.GetCodewindProjectContainers(containers) {
+		if strings.HasSuffix(container.Names[0], projectID) {
+			containerID, containerName = container.ID, strings.TrimPrefix(container.Names[0], "/")
+			break
+		}
+	}
+	if containerID == "" {
+		err := errors.New("no container is running for project " + projectID + ", it may not have been built yet")
+		return &ProjectError{errOpNotFound, err, err.Error()}
+	}
+
+	logStream, dockerErr := docker.GetContainerLogsWithOptions(dockerClient, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Timestamps: true, Follow: follow})
+	if dockerErr != nil {
+		return &ProjectError{errOpGetProject, dockerErr, dockerErr.Desc}
+	}
+	defer logStream.Close()
+
+	err := docker.ScanContainerLogs(logStream, func(line string) {
+		// Docker puts the time before each line when asked for timestamps
+		timestamp, message := "", line
+		if space := strings.Index(line, " "); space > 0 {
+			timestamp, message = line[:space], line[space+1:]
+		}
+		onLine(LogLine{Time: timestamp, Type: LogTypeApp, Log: containerName, Message: message})
+	})
+	if err != nil {
+		return &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return nil
+}
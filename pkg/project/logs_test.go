@@ -0,0 +1,161 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockLogsDockerClient lists the given containers and serves the given log for every container
+type mockLogsDockerClient struct {
+	docker.DockerClient
+	containers []types.Container
+	log        []byte
+}
+
+func (m *mockLogsDockerClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	return m.containers, nil
+}
+
+func (m *mockLogsDockerClient) ContainerLogs(ctx context.Context, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(m.log)), nil
+}
+
+func TestStreamProjectLogs(t *testing.T) {
+	logsRoutes := map[string]mockPFEResponse{
+		"GET /api/v1/projects/abc123/logs":                    {http.StatusOK, ProjectLogs{Build: []ProjectLog{{LogName: "docker.build"}}, App: []ProjectLog{{LogName: "app"}}}},
+		"GET /api/v1/projects/abc123/logs/build/docker.build": {http.StatusOK, "Step 1/2 : FROM node\nStep 2/2 : RUN npm install\n"},
+	}
+
+	t.Run("success case - build logs are read from PFE", func(t *testing.T) {
+		lines := []LogLine{}
+		err := streamProjectLogs(&clientMockPFE{routes: logsRoutes}, nil, &connections.Connection{ID: "local"}, "http://pfe", "abc123", []string{LogTypeBuild}, false, func(line LogLine) {
+			assert.NotEmpty(t, line.Time)
+			line.Time = ""
+			lines = append(lines, line)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []LogLine{
+			{Type: LogTypeBuild, Log: "docker.build", Message: "Step 1/2 : FROM node"},
+			{Type: LogTypeBuild, Log: "docker.build", Message: "Step 2/2 : RUN npm install"},
+		}, lines)
+	})
+
+	t.Run("success case - app logs of a local project are read from its container", func(t *testing.T) {
+		content := "2020-03-02T10:00:00.000000000Z Listening on port 3000\n"
+		header := make([]byte, 8)
+		header[0] = 1
+		binary.BigEndian.PutUint32(header[4:], uint32(len(content)))
+		dockerClient := &mockLogsDockerClient{
+			containers: []types.Container{{ID: "other", Names: []string{"/cw-other-def456"}}, {ID: "container", Names: []string{"/cw-nodeapp-abc123"}}},
+			log:        append(header, content...),
+		}
+
+		lines := []LogLine{}
+		err := streamProjectLogs(&clientMockPFE{}, dockerClient, &connections.Connection{ID: "local"}, "http://pfe", "abc123", []string{LogTypeApp}, false, func(line LogLine) {
+			lines = append(lines, line)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []LogLine{{Time: "2020-03-02T10:00:00.000000000Z", Type: LogTypeApp, Log: "cw-nodeapp-abc123", Message: "Listening on port 3000"}}, lines)
+	})
+
+	t.Run("fail case - a local project without a container", func(t *testing.T) {
+		dockerClient := &mockLogsDockerClient{containers: []types.Container{{ID: "other", Names: []string{"/cw-other-def456"}}}}
+		err := streamProjectLogs(&clientMockPFE{}, dockerClient, &connections.Connection{ID: "local"}, "http://pfe", "abc123", []string{LogTypeApp}, false, func(line LogLine) {})
+		assert.Equal(t, errOpNotFound, err.Op)
+	})
+
+	t.Run("fail case - the project is not found", func(t *testing.T) {
+		err := streamProjectLogs(&clientMockPFE{}, nil, &connections.Connection{ID: "local"}, "http://pfe", "missing", []string{LogTypeBuild}, false, func(line LogLine) {})
+		assert.Equal(t, errOpNotFound, err.Op)
+	})
+}
+
+func TestFollowProjectLogs(t *testing.T) {
+	defaultInterval := logPollInterval
+	logPollInterval = 10 * time.Millisecond
+	defer func() { logPollInterval = defaultInterval }()
+
+	// waitForRequest waits for the client to have been sent the given request since the given number of requests
+	waitForRequest := func(client *clientMockPFE, request string, since int) bool {
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(logPollInterval) {
+			client.mutex.Lock()
+			requests := client.requests[since:]
+			client.mutex.Unlock()
+			for _, sent := range requests {
+				if sent == request {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	setRoute := func(client *clientMockPFE, route string, response *mockPFEResponse) int {
+		client.mutex.Lock()
+		defer client.mutex.Unlock()
+		if response == nil {
+			delete(client.routes, route)
+		} else {
+			client.routes[route] = *response
+		}
+		return len(client.requests)
+	}
+
+	t.Run("success case - a build log created while following is followed, through server errors, until the project is removed", func(t *testing.T) {
+		client := &clientMockPFE{routes: map[string]mockPFEResponse{
+			"GET /api/v1/projects/abc123/logs": {http.StatusOK, ProjectLogs{}},
+		}}
+		lines := make(chan LogLine, 10)
+		result := make(chan *ProjectError)
+		go func() {
+			result <- streamProjectLogs(client, nil, &connections.Connection{ID: "local"}, "http://pfe", "abc123", []string{LogTypeBuild}, true, func(line LogLine) {
+				lines <- line
+			})
+		}()
+		assert.True(t, waitForRequest(client, "GET /api/v1/projects/abc123/logs", 0))
+
+		setRoute(client, "GET /api/v1/projects/abc123/logs/build/docker.build", &mockPFEResponse{http.StatusServiceUnavailable, ""})
+		sent := setRoute(client, "GET /api/v1/projects/abc123/logs", &mockPFEResponse{http.StatusOK, ProjectLogs{Build: []ProjectLog{{LogName: "docker.build"}}}})
+		assert.True(t, waitForRequest(client, "GET /api/v1/projects/abc123/logs/build/docker.build", sent))
+
+		setRoute(client, "GET /api/v1/projects/abc123/logs/build/docker.build", &mockPFEResponse{http.StatusOK, "Step 1/2 : FROM node\nStep 2/2 : RUN npm install\n"})
+		for _, message := range []string{"Step 1/2 : FROM node", "Step 2/2 : RUN npm install"} {
+			select {
+			case line := <-lines:
+				assert.Equal(t, LogLine{Time: line.Time, Type: LogTypeBuild, Log: "docker.build", Message: message}, line)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for " + message)
+			}
+		}
+
+		setRoute(client, "GET /api/v1/projects/abc123/logs", nil)
+		select {
+		case err := <-result:
+			assert.Equal(t, errOpNotFound, err.Op)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the stream to end")
+		}
+		assert.Empty(t, lines)
+	})
+}
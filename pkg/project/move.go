@@ -0,0 +1,120 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// MoveResponse : The result of moving a project to another connection
+type MoveResponse struct {
+	ProjectID          string        `json:"projectID"`
+	SourceProjectID    string        `json:"sourceProjectID"`
+	SourceConnectionID string        `json:"sourceConnectionID"`
+	TargetConnectionID string        `json:"targetConnectionID"`
+	Name               string        `json:"name"`
+	Path               string        `json:"projectPath"`
+	Bind               *BindResponse `json:"bind,omitempty"`
+	Links              []LinkResult  `json:"links"`
+	SourceUnbound      bool          `json:"sourceUnbound"`
+}
+
+// MoveProject : Bind the project given by --id to the connection given by --to, with the same language and type,
+// re-creating its links to projects bound to that connection and unbinding it from its current connection if --unbind is given
+func MoveProject(c *cli.Context) (*MoveResponse, *ProjectError) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	targetConID := strings.TrimSpace(strings.ToLower(c.String("to")))
+	projectPath := strings.TrimSpace(c.String("path"))
+
+	sourceConID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	projErr = checkMoveTarget(projectID, sourceConID, targetConID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	sourceConInfo, sourceConURL, projErr := GetConnectionAndURL(sourceConID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	targetConInfo, targetConURL, projErr := GetConnectionAndURL(targetConID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return moveProject(http.DefaultClient, sourceConInfo, sourceConURL, targetConInfo, targetConURL, projectID, projectPath, c.Bool("unbind"), syncOptionsFromContext(c))
+}
+
+// checkMoveTarget returns an error if the project is already bound to the connection it would be moved to
+func checkMoveTarget(projectID string, sourceConID string, targetConID string) *ProjectError {
+	if sourceConID == targetConID {
+		err := errors.New("project " + projectID + " is already bound to connection " + targetConID)
+		return &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+	return nil
+}
+
+// moveProject binds a project to the target connection, uploading all of its files, and re-creates its links there.
+// If the bind fails, the project is removed from the target and is left bound to its current connection.
+func moveProject(httpClient utils.HTTPClient, sourceConInfo *connections.Connection, sourceConURL string, targetConInfo *connections.Connection, targetConURL string, projectID string, projectPath string, unbindSource bool, options SyncOptions) (*MoveResponse, *ProjectError) {
+	// the bundle has everything needed to bind the project again, including the names of the projects it links to
+	bundle, projErr := ExportProject(httpClient, sourceConInfo, sourceConURL, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	projectPath = firstNonEmpty(projectPath, bundle.Project.Path)
+	projErr = checkProjectPathExists(projectPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+
+	response := &MoveResponse{
+		SourceProjectID:    projectID,
+		SourceConnectionID: sourceConInfo.ID,
+		TargetConnectionID: targetConInfo.ID,
+		Name:               bundle.Project.Name,
+		Path:               projectPath,
+		Links:              []LinkResult{},
+	}
+	bindResponse, newProjectID, projErr := bind(httpClient, targetConInfo, targetConURL, projectPath, bundle.Project.Name, bundle.Project.Language, bundle.Project.ProjectType, options)
+	response.Bind = bindResponse
+	if projErr == nil {
+		projErr = checkBindCompleted(bindResponse)
+	}
+	if projErr != nil {
+		if newProjectID != "" {
+			if unbindErr := unbindProject(httpClient, targetConInfo, targetConURL, newProjectID); unbindErr != nil {
+				logr.Warnf("Unable to remove project %v from connection %v: %v", newProjectID, targetConInfo.ID, unbindErr.Desc)
+			}
+		}
+		return response, projErr
+	}
+	response.ProjectID = newProjectID
+
+	response.Links = recreateLinks(httpClient, targetConInfo, targetConURL, newProjectID, bundle.Links)
+
+	if unbindSource {
+		projErr = unbindProject(httpClient, sourceConInfo, sourceConURL, projectID)
+		if projErr != nil {
+			return response, projErr
+		}
+		response.SourceUnbound = true
+	}
+	return response, nil
+}
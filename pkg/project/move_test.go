@@ -0,0 +1,85 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveProject(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "move_test")
+	defer os.RemoveAll(projectPath)
+	defer RemoveSyncManifest("target-id")
+	// both connections are local so requests to them are not authenticated, and are told apart by their URLs
+	source, target := &connections.Connection{ID: "local"}, &connections.Connection{ID: "local"}
+	newClient := func() *clientMockPFE {
+		return &clientMockPFE{routes: map[string]mockPFEResponse{
+			"GET source/api/v1/projects/frontend-id/":        {http.StatusOK, Project{ProjectID: "frontend-id", Name: "frontend", Language: "javascript", ProjectType: "nodejs", LocationOnDisk: projectPath}},
+			"GET source/api/v1/projects/frontend-id/links":   {http.StatusOK, []Link{{ProjectID: "backend-id", EnvName: "BACKEND_URL"}, {ProjectID: "db-id", EnvName: "DB_URL"}}},
+			"GET source/api/v1/projects/":                    {http.StatusOK, []Project{{ProjectID: "backend-id", Name: "backend"}, {ProjectID: "db-id", Name: "db"}}},
+			"POST source/api/v1/projects/frontend-id/unbind": {http.StatusAccepted, nil},
+			"POST target/api/v1/projects/bind/start":         {http.StatusAccepted, BindResponse{ProjectID: "target-id"}},
+			"PUT target/api/v1/projects/target-id/upload":    {http.StatusOK, nil},
+			"POST target/api/v1/projects/target-id/bind/end": {http.StatusOK, nil},
+			"POST target/api/v1/projects/target-id/unbind":   {http.StatusAccepted, nil},
+			"GET target/api/v1/projects/":                    {http.StatusOK, []Project{{ProjectID: "target-backend-id", Name: "backend"}}},
+			"POST target/api/v1/projects/target-id/links":    {http.StatusAccepted, nil},
+		}}
+	}
+	ioutil.WriteFile(filepath.Join(projectPath, "package.json"), []byte("{}"), 0644)
+
+	t.Run("success case - the project is bound to the target, its links re-created and the source unbound", func(t *testing.T) {
+		client := newClient()
+		response, err := moveProject(client, source, "http://source", target, "http://target", "frontend-id", "", true, SyncOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "target-id", response.ProjectID)
+		assert.Equal(t, projectPath, response.Path)
+		assert.Contains(t, strings.Join(client.requests, "\n"), `POST /api/v1/projects/bind/start {"language":"javascript","projectType":"nodejs","name":"frontend"`)
+		assert.Contains(t, client.requests, "POST /api/v1/projects/frontend-id/unbind")
+		assert.True(t, response.SourceUnbound)
+		assert.Equal(t, LinkStatusCreated, response.Links[0].Status)
+		assert.Equal(t, LinkStatusSkipped, response.Links[1].Status)
+		assert.Contains(t, client.requests, `POST /api/v1/projects/target-id/links {"envName":"BACKEND_URL","targetProjectID":"target-backend-id"}`)
+	})
+
+	t.Run("success case - the source is kept unless it should be unbound", func(t *testing.T) {
+		client := newClient()
+		response, err := moveProject(client, source, "http://source", target, "http://target", "frontend-id", "", false, SyncOptions{})
+		assert.Nil(t, err)
+		assert.False(t, response.SourceUnbound)
+		assert.NotContains(t, client.requests, "POST /api/v1/projects/frontend-id/unbind")
+	})
+
+	t.Run("fail case - a failed bind is removed from the target and the source is kept", func(t *testing.T) {
+		client := newClient()
+		client.routes["PUT target/api/v1/projects/target-id/upload"] = mockPFEResponse{http.StatusBadRequest, nil}
+		response, err := moveProject(client, source, "http://source", target, "http://target", "frontend-id", "", true, SyncOptions{})
+		assert.NotNil(t, err)
+		assert.Equal(t, "", response.ProjectID)
+		assert.Contains(t, client.requests, "POST /api/v1/projects/target-id/unbind")
+		assert.NotContains(t, client.requests, "POST /api/v1/projects/frontend-id/unbind")
+	})
+}
+
+func TestCheckMoveTarget(t *testing.T) {
+	assert.Nil(t, checkMoveTarget("frontend-id", "source", "target"))
+	err := checkMoveTarget("frontend-id", "source", "source")
+	assert.Equal(t, errOpInvalidOptions, err.Op)
+}
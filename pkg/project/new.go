@@ -0,0 +1,244 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// The steps of creating a new project, in the order they run
+const (
+	NewProjectStepDownload = "download"
+	NewProjectStepValidate = "validate"
+	NewProjectStepBind     = "bind"
+	NewProjectStepRollback = "rollback"
+)
+
+// The status of a step of creating a new project
+const (
+	NewProjectStepSuccess = "success"
+	NewProjectStepFailed  = "failed"
+)
+
+type (
+	// NewProjectStep : The outcome of one step of creating a new project
+	NewProjectStep struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// NewProjectResponse : The combined result of downloading, validating and binding a new project
+	NewProjectResponse struct {
+		Status     string              `json:"status"`
+		Path       string              `json:"projectPath"`
+		Name       string              `json:"name"`
+		ProjectID  string              `json:"projectID,omitempty"`
+		Steps      []NewProjectStep    `json:"steps"`
+		Validation *ValidationResponse `json:"validation,omitempty"`
+		Bind       *BindResponse       `json:"bind,omitempty"`
+		RolledBack bool                `json:"rolledBack"`
+	}
+)
+
+// NewProject : Download a project from a template, validate it and bind it, undoing every step if any of them fails.
+// The response describes each step, and is returned whether or not the project was created.
+func NewProject(c *cli.Context, templateOptions TemplateOptions) (*NewProjectResponse, *ProjectError) {
+	url := strings.TrimSpace(c.String("url"))
+	gitCredentials := gitCredentialsFromContext(c)
+	conInfo, conURL, projErr := GetConnectionAndURL(strings.TrimSpace(strings.ToLower(c.String("conid"))))
+	if projErr != nil {
+		return nil, projErr
+	}
+	return createAndBind(c, &http.Client{}, conInfo, conURL, func(projectPath string) *ProjectError {
+		_, projErr := DownloadTemplate(projectPath, url, gitCredentials, templateOptions)
+		return projErr
+	})
+}
+
+// BindFromGit : Download a GitHub repository at a branch, tag or commit, validate it and bind it,
+// undoing every step if any of them fails
+func BindFromGit(c *cli.Context) (*NewProjectResponse, *ProjectError) {
+	if c.Bool("dry-run") {
+		err := errors.New(textGitDryRun)
+		return nil, &ProjectError{errOpInvalidOptions, err, textGitDryRun}
+	}
+	conInfo, conURL, projErr := GetConnectionAndURL(strings.TrimSpace(strings.ToLower(c.String("conid"))))
+	if projErr != nil {
+		return nil, projErr
+	}
+	return bindFromGit(c, &http.Client{}, conInfo, conURL)
+}
+
+func bindFromGit(c *cli.Context, httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string) (*NewProjectResponse, *ProjectError) {
+	gitURL := strings.TrimSpace(c.String("git"))
+	ref := strings.TrimSpace(c.String("ref"))
+	gitCredentials := gitCredentialsFromContext(c)
+	return createAndBind(c, httpClient, conInfo, conURL, func(projectPath string) *ProjectError {
+		projErr := checkProjectDirIsEmpty(projectPath)
+		if projErr != nil {
+			return projErr
+		}
+		err := utils.DownloadFromURLAtRefThenExtract(gitURL, ref, projectPath, gitCredentials)
+		if err != nil {
+			return &ProjectError{errOpBind, err, err.Error()}
+		}
+		return nil
+	})
+}
+
+// createAndBind runs download to write the project's files, then validates and binds the project, undoing every step if any fails.
+// A language or type given on the command line is used instead of the one validation detects.
+func createAndBind(c *cli.Context, httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, download func(projectPath string) *ProjectError) (*NewProjectResponse, *ProjectError) {
+	projectPath := strings.TrimSpace(c.String("path"))
+	name := strings.TrimSpace(c.String("name"))
+	if name == "" {
+		name = filepath.Base(projectPath)
+	}
+
+	response := &NewProjectResponse{Status: NewProjectStepFailed, Path: projectPath, Name: name, Steps: []NewProjectStep{}}
+	pathExisted := utils.PathExists(projectPath)
+	rollback := func(projectID string, cause *ProjectError) *ProjectError {
+		return response.rollback(httpClient, conInfo, conURL, projectPath, pathExisted, projectID, cause)
+	}
+
+	projErr := download(projectPath)
+	response.addStep(NewProjectStepDownload, projErr)
+	if projErr != nil {
+		// a directory that was refused has had nothing written to it, so it must not be removed
+		if projErr.Err == errProjectPathNonEmpty || projErr.Err == errNoProjectPath {
+			return response, projErr
+		}
+		return response, rollback("", projErr)
+	}
+
+	validation, projErr := validateProject(httpClient, conInfo, conURL, c)
+	response.Validation = validation
+	if projErr == nil && validation.Status != NewProjectStepSuccess {
+		err := fmt.Errorf("project validation failed: %v", validation.Result)
+		projErr = &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+	response.addStep(NewProjectStepValidate, projErr)
+	if projErr != nil {
+		return response, rollback("", projErr)
+	}
+	projectType, _ := validation.Result.(ProjectType)
+	language := firstNonEmpty(c.String("language"), projectType.Language)
+	buildType := firstNonEmpty(c.String("type"), projectType.BuildType)
+
+	bindResponse, projectID, projErr := bind(httpClient, conInfo, conURL, projectPath, name, language, buildType, syncOptionsFromContext(c))
+	response.Bind = bindResponse
+	response.ProjectID = projectID
+	if projErr == nil {
+		projErr = checkBindCompleted(bindResponse)
+	}
+	response.addStep(NewProjectStepBind, projErr)
+	if projErr != nil {
+		return response, rollback(projectID, projErr)
+	}
+
+	response.Status = NewProjectStepSuccess
+	return response, nil
+}
+
+// gitCredentialsFromContext reads the GitHub username and password flags, using no credentials unless both are given
+func gitCredentialsFromContext(c *cli.Context) utils.GitCredentials {
+	gitCredentials := utils.GitCredentials{}
+	if c.String("username") != "" && c.String("password") != "" {
+		gitCredentials.Username = c.String("username")
+		gitCredentials.Password = c.String("password")
+	}
+	return gitCredentials
+}
+
+// addStep records the outcome of a step
+func (r *NewProjectResponse) addStep(name string, projErr *ProjectError) {
+	step := NewProjectStep{Name: name, Status: NewProjectStepSuccess}
+	if projErr != nil {
+		step.Status = NewProjectStepFailed
+		step.Error = projErr.Desc
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// rollback unbinds the project if it was bound and removes the files that were written, returning the error that caused it
+func (r *NewProjectResponse) rollback(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectPath string, pathExisted bool, projectID string, cause *ProjectError) *ProjectError {
+	problems := []string{}
+	if projectID != "" {
+		if projErr := unbindProject(httpClient, conInfo, conURL, projectID); projErr != nil {
+			problems = append(problems, "unable to unbind project "+projectID+": "+projErr.Desc)
+		}
+	}
+	if err := removeCreatedProjectFiles(projectPath, pathExisted); err != nil {
+		problems = append(problems, "unable to remove "+projectPath+": "+err.Error())
+	}
+
+	var rollbackErr *ProjectError
+	if len(problems) > 0 {
+		err := errors.New(strings.Join(problems, "; "))
+		rollbackErr = &ProjectError{errOpCreateProject, err, err.Error()}
+	}
+	r.addStep(NewProjectStepRollback, rollbackErr)
+	r.RolledBack = rollbackErr == nil
+	return cause
+}
+
+// checkBindCompleted returns an error if a bind did not finish or did not upload every file
+func checkBindCompleted(bindResponse *BindResponse) *ProjectError {
+	if bindResponse.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unable to complete the bind: %v", bindResponse.Status)
+		return &ProjectError{errOpBind, err, err.Error()}
+	}
+	if failed := FailedUploads(bindResponse.UploadedFiles); len(failed) > 0 {
+		err := fmt.Errorf("%v files failed to upload, the first was %v: %v", len(failed), failed[0].FilePath, failed[0].Status)
+		return &ProjectError{errOpBind, err, err.Error()}
+	}
+	return nil
+}
+
+// removeCreatedProjectFiles removes what was written to a project's path, leaving a directory that existed before empty
+func removeCreatedProjectFiles(projectPath string, pathExisted bool) error {
+	if !pathExisted {
+		return os.RemoveAll(projectPath)
+	}
+	files, err := ioutil.ReadDir(projectPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.RemoveAll(filepath.Join(projectPath, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unbindProject unbinds a project, removing what the CLI stored about it
+func unbindProject(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string) *ProjectError {
+	projErr := Unbind(httpClient, conInfo, conURL, projectID)
+	if projErr != nil {
+		return projErr
+	}
+	RemoveConnectionFile(projectID)
+	RemoveSyncManifest(projectID)
+	return nil
+}
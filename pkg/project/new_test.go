@@ -0,0 +1,200 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+// newProjectRoutes are the PFE routes a project is validated, bound and unbound with, binding it as bound-id
+func newProjectRoutes() map[string]mockPFEResponse {
+	return map[string]mockPFEResponse{
+		"GET /api/v1/extensions":                  {http.StatusOK, []utils.Extension{}},
+		"POST /api/v1/projects/bind/start":        {http.StatusAccepted, BindResponse{ProjectID: "bound-id"}},
+		"PUT /api/v1/projects/bound-id/upload":    {http.StatusOK, nil},
+		"POST /api/v1/projects/bound-id/bind/end": {http.StatusOK, nil},
+		"POST /api/v1/projects/bound-id/unbind":   {http.StatusAccepted, nil},
+	}
+}
+
+func newProjectTestContext(projectPath string) *cli.Context {
+	set := flag.NewFlagSet("tests", 0)
+	set.String("path", projectPath, "")
+	set.String("conid", "local", "")
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestNewProject(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "new_test")
+	defer os.RemoveAll(testDir)
+	defer RemoveSyncManifest("bound-id")
+	source := newTemplateServer("aaa", testTemplateFiles)
+	defer source.server.Close()
+	download := func(projectPath string) *ProjectError {
+		_, projErr := downloadTemplate(filepath.Join(testDir, "cache"), projectPath, source.url(), utils.GitCredentials{}, TemplateOptions{})
+		return projErr
+	}
+
+	tests := map[string]struct {
+		routes         map[string]mockPFEResponse
+		wantStatus     string
+		wantSteps      []string
+		wantUnbound    bool
+		wantFilesExist bool
+	}{
+		"success case - every step succeeds": {
+			routes:         map[string]mockPFEResponse{},
+			wantStatus:     NewProjectStepSuccess,
+			wantSteps:      []string{"download:success", "validate:success", "bind:success"},
+			wantFilesExist: true,
+		},
+		"fail case - validation fails, the files are removed": {
+			routes:     map[string]mockPFEResponse{"GET /api/v1/extensions": {http.StatusOK, "not json"}},
+			wantStatus: NewProjectStepFailed,
+			wantSteps:  []string{"download:success", "validate:failed", "rollback:success"},
+		},
+		"fail case - an upload fails, the project is unbound and the files are removed": {
+			routes:      map[string]mockPFEResponse{"PUT /api/v1/projects/bound-id/upload": {http.StatusBadRequest, nil}},
+			wantStatus:  NewProjectStepFailed,
+			wantSteps:   []string{"download:success", "validate:success", "bind:failed", "rollback:success"},
+			wantUnbound: true,
+		},
+		"fail case - bind does not complete, the project is unbound": {
+			routes:      map[string]mockPFEResponse{"POST /api/v1/projects/bound-id/bind/end": {http.StatusInternalServerError, nil}},
+			wantStatus:  NewProjectStepFailed,
+			wantSteps:   []string{"download:success", "validate:success", "bind:failed", "rollback:success"},
+			wantUnbound: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			routes := newProjectRoutes()
+			for route, response := range test.routes {
+				routes[route] = response
+			}
+			client := &clientMockPFE{routes: routes}
+			projectPath := filepath.Join(testDir, "myproject")
+			defer os.RemoveAll(projectPath)
+
+			response, err := createAndBind(newProjectTestContext(projectPath), client, &mockConnection, "http://pfe", download)
+			if test.wantStatus == NewProjectStepSuccess {
+				assert.Nil(t, err)
+				assert.Equal(t, "bound-id", response.ProjectID)
+			} else {
+				assert.NotNil(t, err)
+				assert.True(t, response.RolledBack)
+			}
+			gotSteps := []string{}
+			for _, step := range response.Steps {
+				gotSteps = append(gotSteps, step.Name+":"+step.Status)
+			}
+			assert.Equal(t, test.wantStatus, response.Status)
+			assert.Equal(t, "myproject", response.Name)
+			assert.Equal(t, test.wantSteps, gotSteps)
+			if test.wantUnbound {
+				assert.Contains(t, client.requests, "POST /api/v1/projects/bound-id/unbind")
+			} else {
+				assert.NotContains(t, client.requests, "POST /api/v1/projects/bound-id/unbind")
+			}
+			assert.Equal(t, test.wantFilesExist, utils.PathExists(projectPath))
+		})
+	}
+
+	t.Run("fail case - a directory that existed before is emptied but kept", func(t *testing.T) {
+		projectPath := filepath.Join(testDir, "existing")
+		os.MkdirAll(projectPath, 0777)
+		defer os.RemoveAll(projectPath)
+
+		_, err := createAndBind(newProjectTestContext(projectPath), &clientMockPFE{}, &mockConnection, "http://pfe", download)
+		assert.NotNil(t, err)
+		empty, _ := utils.DirIsEmpty(projectPath)
+		assert.True(t, empty)
+	})
+
+	t.Run("fail case - a non-empty directory is left alone", func(t *testing.T) {
+		projectPath := filepath.Join(testDir, "nonempty")
+		os.MkdirAll(projectPath, 0777)
+		ioutil.WriteFile(filepath.Join(projectPath, "keep.txt"), []byte("keep"), 0644)
+		defer os.RemoveAll(projectPath)
+
+		response, err := createAndBind(newProjectTestContext(projectPath), &clientMockPFE{routes: newProjectRoutes()}, &mockConnection, "http://pfe", download)
+		assert.Equal(t, errProjectPathNonEmpty, err.Err)
+		assert.False(t, response.RolledBack)
+		assert.FileExists(t, filepath.Join(projectPath, "keep.txt"))
+	})
+}
+
+func TestBindFromGit(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "new_test")
+	defer os.RemoveAll(testDir)
+	defer RemoveSyncManifest("bound-id")
+	source := newTemplateServer("aaa", map[string]string{"package.json": "{}"})
+	defer source.server.Close()
+
+	bindFromGitContext := func(projectPath string, language string) *cli.Context {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("path", projectPath, "")
+		set.String("name", "fromgit", "")
+		set.String("git", source.url(), "")
+		set.String("ref", "v1.0", "")
+		set.String("language", language, "")
+		set.String("conid", "local", "")
+		set.Bool("dry-run", false, "")
+		return cli.NewContext(nil, set, nil)
+	}
+
+	t.Run("success case - the repository is downloaded, validated and bound", func(t *testing.T) {
+		client := &clientMockPFE{routes: newProjectRoutes()}
+		projectPath := filepath.Join(testDir, "fromgit")
+
+		response, err := bindFromGit(bindFromGitContext(projectPath, "typescript"), client, &mockConnection, "http://pfe")
+		assert.Nil(t, err)
+		assert.Equal(t, NewProjectStepSuccess, response.Status)
+		assert.Equal(t, 1, source.downloads)
+		assert.Contains(t, strings.Join(client.requests, "\n"), `POST /api/v1/projects/bind/start {"language":"typescript","projectType":"nodejs","name":"fromgit"`)
+		assert.FileExists(t, filepath.Join(projectPath, "package.json"))
+	})
+
+	t.Run("fail case - a failed download removes the project directory", func(t *testing.T) {
+		source.offline = true
+		defer func() { source.offline = false }()
+		projectPath := filepath.Join(testDir, "missingref")
+
+		response, err := bindFromGit(bindFromGitContext(projectPath, ""), &clientMockPFE{routes: newProjectRoutes()}, &mockConnection, "http://pfe")
+		assert.Contains(t, err.Desc, "File download failed")
+		assert.True(t, response.RolledBack)
+		assert.False(t, utils.PathExists(projectPath))
+	})
+
+	t.Run("fail case - a non-empty directory is refused before downloading", func(t *testing.T) {
+		projectPath := filepath.Join(testDir, "nonempty")
+		os.MkdirAll(projectPath, 0777)
+		ioutil.WriteFile(filepath.Join(projectPath, "keep.txt"), []byte("keep"), 0644)
+		downloads := source.downloads
+
+		response, err := bindFromGit(bindFromGitContext(projectPath, ""), &clientMockPFE{routes: newProjectRoutes()}, &mockConnection, "http://pfe")
+		assert.Equal(t, errProjectPathNonEmpty, err.Err)
+		assert.False(t, response.RolledBack)
+		assert.Equal(t, downloads, source.downloads)
+		assert.FileExists(t, filepath.Join(projectPath, "keep.txt"))
+	})
+}
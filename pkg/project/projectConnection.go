@@ -22,6 +22,19 @@ import (
 	"github.com/eclipse/codewind-installer/pkg/connections"
 )
 
+// GetConnectionAndURL : Get a connection by its ID and the URL of its PFE
+func GetConnectionAndURL(conID string) (*connections.Connection, string, *ProjectError) {
+	conInfo, conInfoErr := connections.GetConnectionByID(conID)
+	if conInfoErr != nil {
+		return nil, "", &ProjectError{errOpConNotFound, conInfoErr.Err, conInfoErr.Desc}
+	}
+	conURL, configErr := config.PFEOriginFromConnection(conInfo)
+	if configErr != nil {
+		return nil, "", &ProjectError{errOpConNotFound, configErr.Err, configErr.Desc}
+	}
+	return conInfo, conURL, nil
+}
+
 // GetConnectionID : Gets the the connectionID for a given projectID
 func GetConnectionID(projectID string) (string, *ProjectError) {
 	allConnections, getConConfigErr := connections.GetConnectionsConfig()
@@ -72,6 +85,11 @@ func RemoveConnectionFile(projectID string) *ProjectError {
 
 // getProjectConnectionConfigDir : Get directory path to the connection file
 func getProjectConnectionConfigDir() string {
+	return path.Join(getCodewindHomeDir(), "config", "connections")
+}
+
+// getCodewindHomeDir : Get the path to the .codewind directory used to store CLI state
+func getCodewindHomeDir() string {
 	val, isSet := os.LookupEnv("CHE_API_EXTERNAL")
 	homeDir := ""
 	if isSet && (val != "") {
@@ -90,7 +108,7 @@ func getProjectConnectionConfigDir() string {
 			homeDir = os.Getenv("HOME")
 		}
 	}
-	return path.Join(homeDir, ".codewind", "config", "connections")
+	return path.Join(homeDir, ".codewind")
 }
 
 // getConnectionFilename : Get full file path of connection file
@@ -45,7 +45,12 @@ const (
 	errOpInvalidOptions  = "proj_options_invalid"
 	errOpSync            = "proj_sync"
 	errOpSyncRef         = "proj_sync_ref"
+	errOpSyncLimit       = "proj_sync_limit"
+	errOpWatch           = "proj_watch"
 	errOpWriteCwSettings = "proj_write_cw_settings"
+	errOpTemplateCache   = "proj_template_cache"
+	errOpWaitFailed      = "proj_wait_failed"
+	errOpWaitTimeout     = "proj_wait_timeout"
 )
 
 const (
@@ -65,6 +70,11 @@ const (
 	textProjectLinkTargetNotFound = "target project not found on Codewind server"
 	textProjectLinkConflict       = "project link env is already in use"
 	textInvalidRequest            = "request parameters are invalid"
+	textLanguageTypeRequired      = "--language and --type are required unless the project is bound with --git"
+	textGitDryRun                 = "--dry-run cannot be used with --git, as there are no local files to list until the repository is downloaded"
+	textWorkspaceNotDir           = "workspace is not a directory"
+	textImportSource              = "give either --workspace or --bundle to import"
+	textTemplateNotCached         = "template %v has not been cached, run 'cwctl templates cache' while online to cache it"
 )
 
 // ProjectError : Error formatted in JSON containing an errorOp and a description from
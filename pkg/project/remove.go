@@ -62,6 +62,9 @@ func RemoveProject(c *cli.Context) *ProjectError {
 	// We can ignore errors as we are no longer creating this file
 	RemoveConnectionFile(projectID)
 
+	// Delete the sync manifest, a project bound again will be synced in full
+	RemoveSyncManifest(projectID)
+
 	// Delete the source if the flag is set
 	if deleteFiles {
 		var err = os.RemoveAll(projectPath)
@@ -0,0 +1,553 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+const (
+	cwSettingsFile     = ".cw-settings"
+	legacySettingsFile = ".mc-settings"
+
+	// CWSettingsSchemaVersion : The version of the .cw-settings schema this CLI writes.
+	// A file without a schemaVersion was written before the schema was versioned, and is version 0.
+	CWSettingsSchemaVersion = 1
+)
+
+// The kinds of value a .cw-settings field can have
+const (
+	settingsKindString   = "string"
+	settingsKindBool     = "bool"
+	settingsKindStrings  = "strings"  // an array of strings
+	settingsKindPort     = "port"     // a string holding a port number, or blank
+	settingsKindSeconds  = "seconds"  // a string holding a whole number of seconds, or blank
+	settingsKindByteSize = "byteSize" // a string such as 50MB
+	settingsKindPolicy   = "policy"   // one of the FileSizePolicy constants
+	settingsKindVersion  = "version"  // a whole number
+)
+
+// Severities of the problems found validating .cw-settings
+const (
+	SettingsProblemError   = "error"
+	SettingsProblemWarning = "warning"
+)
+
+type (
+	// settingsField describes one field of the .cw-settings schema
+	settingsField struct {
+		Name     string
+		Kind     string
+		Nullable bool
+		Since    int // the schema version that added the field
+	}
+
+	// SettingsProblem : A problem found validating a .cw-settings file, and where in the file it is
+	SettingsProblem struct {
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+		Field    string `json:"field,omitempty"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+	}
+
+	// SettingsValidation : The result of validating a .cw-settings file
+	SettingsValidation struct {
+		Path          string            `json:"path"`
+		Valid         bool              `json:"valid"`
+		SchemaVersion int               `json:"schemaVersion"`
+		Problems      []SettingsProblem `json:"problems"`
+	}
+
+	// SettingsMigration : The changes made upgrading a .cw-settings file to the current schema
+	SettingsMigration struct {
+		Path        string   `json:"path"`
+		FromVersion int      `json:"fromVersion"`
+		ToVersion   int      `json:"toVersion"`
+		Changes     []string `json:"changes"`
+	}
+
+	// settingsValue is a field read from a .cw-settings file, with where it was read from
+	settingsValue struct {
+		Name   string
+		Raw    json.RawMessage
+		Line   int
+		Column int
+	}
+
+	// settingsDocument is a .cw-settings file, keeping the order of its fields so it can be written back unchanged
+	settingsDocument struct {
+		Fields []settingsValue
+	}
+)
+
+// cwSettingsSchema is every field the current schema allows, in the order they are written
+var cwSettingsSchema = []settingsField{
+	{"schemaVersion", settingsKindVersion, false, 1},
+	{"contextRoot", settingsKindString, false, 0},
+	{"internalPort", settingsKindPort, false, 0},
+	{"healthCheck", settingsKindString, false, 0},
+	{"internalDebugPort", settingsKindPort, true, 0},
+	{"isHttps", settingsKindBool, false, 0},
+	{"ignoredPaths", settingsKindStrings, false, 0},
+	{"mavenProfiles", settingsKindStrings, false, 0},
+	{"mavenProperties", settingsKindStrings, false, 0},
+	{"statusPingTimeout", settingsKindSeconds, false, 0},
+	{"maxFileSize", settingsKindByteSize, false, 1},
+	{"maxFileSizePolicy", settingsKindPolicy, false, 1},
+	{"uploadRateLimit", settingsKindByteSize, false, 1},
+}
+
+// settingsMigrations upgrade a .cw-settings file from each schema version to the next, the first from version 0 to 1.
+// There is one for every version up to CWSettingsSchemaVersion.
+var settingsMigrations = []func(document *settingsDocument) []string{
+	migrateSettingsToVersion1,
+}
+
+// lookupSettingsField returns the schema of a field, or nil if the schema does not have it
+func lookupSettingsField(name string) *settingsField {
+	for i := range cwSettingsSchema {
+		if cwSettingsSchema[i].Name == name {
+			return &cwSettingsSchema[i]
+		}
+	}
+	return nil
+}
+
+// ValidateSettings : Validate a project's .cw-settings against the schema, reporting where each problem is
+func ValidateSettings(projectPath string) (*SettingsValidation, *ProjectError) {
+	settingsPath := filepath.Join(projectPath, cwSettingsFile)
+	data, err := ioutil.ReadFile(settingsPath)
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+	validation := validateSettings(data)
+	validation.Path = settingsPath
+	return validation, nil
+}
+
+// ShowSettings : Read the fields of a project's .cw-settings
+func ShowSettings(projectPath string) (map[string]interface{}, *ProjectError) {
+	document, projErr := readSettingsDocument(filepath.Join(projectPath, cwSettingsFile))
+	if projErr != nil {
+		return nil, projErr
+	}
+	settings := make(map[string]interface{})
+	for _, field := range document.Fields {
+		var value interface{}
+		json.Unmarshal(field.Raw, &value)
+		settings[field.Name] = value
+	}
+	return settings, nil
+}
+
+// SetSetting : Set a field of a project's .cw-settings, creating the file if it does not exist.
+// Array fields are given as comma separated values.
+func SetSetting(projectPath string, name string, value string) *ProjectError {
+	field := lookupSettingsField(name)
+	if field == nil || field.Kind == settingsKindVersion {
+		err := fmt.Errorf("%v is not a field that can be set, expected one of: %v", name, strings.Join(settableSettingsFields(), ", "))
+		return &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+	raw, err := settingsValueFromString(*field, value)
+	if err != nil {
+		err = fmt.Errorf("invalid value for %v: %v", name, err)
+		return &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+
+	settingsPath := filepath.Join(projectPath, cwSettingsFile)
+	document := &settingsDocument{}
+	if utils.PathExists(settingsPath) {
+		var projErr *ProjectError
+		document, projErr = readSettingsDocument(settingsPath)
+		if projErr != nil {
+			return projErr
+		}
+	} else {
+		version, _ := json.Marshal(CWSettingsSchemaVersion)
+		document.set("schemaVersion", version)
+	}
+	document.set(name, raw)
+	return document.write(settingsPath)
+}
+
+// MigrateSettings : Upgrade a project's settings to the current schema, renaming a legacy .mc-settings file first
+func MigrateSettings(projectPath string) (*SettingsMigration, *ProjectError) {
+	settingsPath := filepath.Join(projectPath, cwSettingsFile)
+	migration := &SettingsMigration{Path: settingsPath, ToVersion: CWSettingsSchemaVersion, Changes: []string{}}
+
+	legacyPath := filepath.Join(projectPath, legacySettingsFile)
+	if utils.PathExists(legacyPath) && !utils.PathExists(settingsPath) {
+		projErr := renameLegacySettings(legacyPath, settingsPath)
+		if projErr != nil {
+			return nil, projErr
+		}
+		migration.Changes = append(migration.Changes, "renamed "+legacySettingsFile+" to "+cwSettingsFile)
+	}
+
+	document, projErr := readSettingsDocument(settingsPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+	migration.FromVersion = document.schemaVersion()
+	if migration.FromVersion > CWSettingsSchemaVersion {
+		err := fmt.Errorf("%v is schema version %v, which is newer than this CLI supports (%v)", cwSettingsFile, migration.FromVersion, CWSettingsSchemaVersion)
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	if migration.FromVersion == CWSettingsSchemaVersion {
+		return migration, nil
+	}
+
+	for version := migration.FromVersion; version < CWSettingsSchemaVersion; version++ {
+		migration.Changes = append(migration.Changes, settingsMigrations[version](document)...)
+		raw, _ := json.Marshal(version + 1)
+		document.set("schemaVersion", raw)
+		migration.Changes = append(migration.Changes, fmt.Sprintf("set schemaVersion to %v", version+1))
+	}
+	projErr = document.write(settingsPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return migration, nil
+}
+
+// migrateSettingsToVersion1 converts the values older CLIs and IDEs wrote with the wrong type
+func migrateSettingsToVersion1(document *settingsDocument) []string {
+	changes := []string{}
+	for i, value := range document.Fields {
+		field := lookupSettingsField(value.Name)
+		if field == nil {
+			continue
+		}
+		var decoded interface{}
+		if json.Unmarshal(value.Raw, &decoded) != nil {
+			continue
+		}
+		var converted interface{}
+		switch field.Kind {
+		case settingsKindPort, settingsKindSeconds:
+			if number, ok := decoded.(float64); ok {
+				converted = strconv.FormatFloat(number, 'f', -1, 64)
+			}
+		case settingsKindStrings:
+			if text, ok := decoded.(string); ok {
+				converted = []string{text}
+				if text == "" {
+					converted = []string{}
+				}
+			}
+		case settingsKindBool:
+			if text, ok := decoded.(string); ok {
+				if parsed, err := strconv.ParseBool(text); err == nil {
+					converted = parsed
+				}
+			}
+		}
+		if converted != nil {
+			document.Fields[i].Raw, _ = json.Marshal(converted)
+			changes = append(changes, fmt.Sprintf("converted %v from %v to %v", value.Name, string(value.Raw), string(document.Fields[i].Raw)))
+		}
+	}
+	return changes
+}
+
+// validateSettings checks the contents of a .cw-settings file against the schema
+func validateSettings(data []byte) *SettingsValidation {
+	validation := &SettingsValidation{Problems: []SettingsProblem{}}
+	document, problem := parseSettingsDocument(data)
+	if problem != nil {
+		validation.Problems = append(validation.Problems, *problem)
+		return validation
+	}
+	validation.SchemaVersion = document.schemaVersion()
+
+	seen := make(map[string]settingsValue)
+	for _, value := range document.Fields {
+		report := func(severity string, message string) {
+			validation.Problems = append(validation.Problems, SettingsProblem{value.Line, value.Column, value.Name, severity, message})
+		}
+		if first, found := seen[value.Name]; found {
+			report(SettingsProblemError, fmt.Sprintf("duplicate field, it is already set on line %v", first.Line))
+			continue
+		}
+		seen[value.Name] = value
+
+		field := lookupSettingsField(value.Name)
+		if field == nil {
+			report(SettingsProblemWarning, "unknown field, it is not used by Codewind")
+			continue
+		}
+		if err := checkSettingsValue(*field, value.Raw); err != nil {
+			report(SettingsProblemError, err.Error())
+		} else if field.Since > validation.SchemaVersion && field.Kind != settingsKindVersion {
+			report(SettingsProblemWarning, fmt.Sprintf("the field was added in schema version %v, but the file is version %v, run 'cwctl project settings migrate' to upgrade it", field.Since, validation.SchemaVersion))
+		}
+	}
+
+	if version, found := seen["schemaVersion"]; !found {
+		validation.Problems = append(validation.Problems, SettingsProblem{1, 1, "schemaVersion", SettingsProblemWarning, "the file has no schemaVersion, run 'cwctl project settings migrate' to upgrade it"})
+	} else if validation.SchemaVersion > CWSettingsSchemaVersion {
+		validation.Problems = append(validation.Problems, SettingsProblem{version.Line, version.Column, "schemaVersion", SettingsProblemError, fmt.Sprintf("schema version %v is newer than this CLI supports (%v)", validation.SchemaVersion, CWSettingsSchemaVersion)})
+	}
+
+	validation.Problems = sortedSettingsProblems(validation.Problems)
+	validation.Valid = true
+	for _, problem := range validation.Problems {
+		if problem.Severity == SettingsProblemError {
+			validation.Valid = false
+		}
+	}
+	return validation
+}
+
+// checkSettingsValue returns an error describing how a value does not match its field's kind
+func checkSettingsValue(field settingsField, raw json.RawMessage) error {
+	if string(raw) == "null" {
+		if field.Nullable {
+			return nil
+		}
+		return errors.New("must not be null")
+	}
+	switch field.Kind {
+	case settingsKindBool:
+		var value bool
+		if json.Unmarshal(raw, &value) != nil {
+			return errors.New("must be true or false")
+		}
+	case settingsKindStrings:
+		var value []string
+		if json.Unmarshal(raw, &value) != nil {
+			return errors.New("must be an array of strings")
+		}
+	case settingsKindVersion:
+		var value int
+		if json.Unmarshal(raw, &value) != nil || value < 0 {
+			return errors.New("must be a whole number")
+		}
+	default:
+		var value string
+		if json.Unmarshal(raw, &value) != nil {
+			return errors.New("must be a string")
+		}
+		return checkSettingsString(field, value)
+	}
+	return nil
+}
+
+// checkSettingsString checks the text of a string field
+func checkSettingsString(field settingsField, value string) error {
+	switch field.Kind {
+	case settingsKindPort:
+		if port, err := strconv.Atoi(value); value != "" && (err != nil || port < 1 || port > 65535) {
+			return fmt.Errorf("must be a port number between 1 and 65535, but was %q", value)
+		}
+	case settingsKindSeconds:
+		if seconds, err := strconv.Atoi(value); value != "" && (err != nil || seconds < 0) {
+			return fmt.Errorf("must be a whole number of seconds, but was %q", value)
+		}
+	case settingsKindByteSize:
+		if _, err := parseByteSize(value); err != nil {
+			return fmt.Errorf("%v, but was %q", err.Error(), value)
+		}
+	case settingsKindPolicy:
+		switch strings.ToLower(value) {
+		case "", FileSizePolicyWarn, FileSizePolicySkip, FileSizePolicyFail:
+		default:
+			return fmt.Errorf("must be one of %v, %v or %v, but was %q", FileSizePolicyWarn, FileSizePolicySkip, FileSizePolicyFail, value)
+		}
+	}
+	return nil
+}
+
+// settingsValueFromString converts a value given on the command line to the JSON for a field
+func settingsValueFromString(field settingsField, value string) (json.RawMessage, error) {
+	switch field.Kind {
+	case settingsKindBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return json.Marshal(parsed)
+	case settingsKindStrings:
+		values := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return json.Marshal(values)
+	}
+	if err := checkSettingsString(field, value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// settableSettingsFields returns the names of the fields SetSetting accepts
+func settableSettingsFields() []string {
+	names := []string{}
+	for _, field := range cwSettingsSchema {
+		if field.Kind != settingsKindVersion {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// readSettingsDocument reads a .cw-settings file, failing if it is not a JSON object
+func readSettingsDocument(settingsPath string) (*settingsDocument, *ProjectError) {
+	data, err := ioutil.ReadFile(settingsPath)
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+	document, problem := parseSettingsDocument(data)
+	if problem != nil {
+		err := fmt.Errorf("%v line %v column %v: %v", cwSettingsFile, problem.Line, problem.Column, problem.Message)
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	return document, nil
+}
+
+// parseSettingsDocument reads the fields of a JSON object in order, recording the line and column of each
+func parseSettingsDocument(data []byte) (*settingsDocument, *SettingsProblem) {
+	syntaxProblem := func(err error, offset int64) *SettingsProblem {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			offset = syntaxErr.Offset
+		}
+		line, column := lineAndColumn(data, offset)
+		return &SettingsProblem{Line: line, Column: column, Severity: SettingsProblemError, Message: "invalid JSON: " + err.Error()}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, syntaxProblem(err, decoder.InputOffset())
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, syntaxProblem(errors.New("the settings must be a JSON object"), 0)
+	}
+
+	document := &settingsDocument{Fields: []settingsValue{}}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, syntaxProblem(err, decoder.InputOffset())
+		}
+		name, _ := token.(string)
+		// the key has just been read, so its opening quote is the last one before its closing quote
+		keyEnd := decoder.InputOffset()
+		keyStart := int64(bytes.LastIndexByte(data[:keyEnd-1], '"'))
+		line, column := lineAndColumn(data, keyStart)
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, syntaxProblem(err, decoder.InputOffset())
+		}
+		document.Fields = append(document.Fields, settingsValue{name, raw, line, column})
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, syntaxProblem(err, decoder.InputOffset())
+	}
+	if _, err := decoder.Token(); err == nil {
+		return nil, syntaxProblem(errors.New("unexpected content after the settings object"), decoder.InputOffset())
+	}
+	return document, nil
+}
+
+// lineAndColumn converts a byte offset in data to a line and column, both counted from 1
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// schemaVersion returns the version of the schema the document says it uses, 0 if it does not say
+func (d *settingsDocument) schemaVersion() int {
+	for _, field := range d.Fields {
+		if field.Name == "schemaVersion" {
+			var version int
+			json.Unmarshal(field.Raw, &version)
+			return version
+		}
+	}
+	return 0
+}
+
+// set replaces the value of a field, adding it if it is not in the document. A new schemaVersion goes first.
+func (d *settingsDocument) set(name string, raw json.RawMessage) {
+	for i := range d.Fields {
+		if d.Fields[i].Name == name {
+			d.Fields[i].Raw = raw
+			return
+		}
+	}
+	value := settingsValue{Name: name, Raw: raw}
+	if name == "schemaVersion" {
+		d.Fields = append([]settingsValue{value}, d.Fields...)
+		return
+	}
+	d.Fields = append(d.Fields, value)
+}
+
+// write writes the document as indented JSON, keeping the order of its fields
+func (d *settingsDocument) write(settingsPath string) *ProjectError {
+	var buffer bytes.Buffer
+	buffer.WriteString("{\n")
+	for i, field := range d.Fields {
+		name, _ := json.Marshal(field.Name)
+		var value bytes.Buffer
+		if err := json.Indent(&value, field.Raw, "  ", "  "); err != nil {
+			return &ProjectError{errOpFileWrite, err, err.Error()}
+		}
+		buffer.WriteString("  " + string(name) + ": " + value.String())
+		if i < len(d.Fields)-1 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("}\n")
+
+	// File permission 0644 grants read and write access to the owner
+	err := ioutil.WriteFile(settingsPath, buffer.Bytes(), 0644)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+// sortedSettingsProblems orders problems by where they are in the file
+func sortedSettingsProblems(problems []SettingsProblem) []SettingsProblem {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems
+}
@@ -0,0 +1,166 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSettings(t *testing.T) {
+	tests := map[string]struct {
+		settings     string
+		wantValid    bool
+		wantProblems []SettingsProblem
+	}{
+		"success case - valid settings": {
+			settings:     "{\n  \"schemaVersion\": 1,\n  \"internalPort\": \"9080\",\n  \"ignoredPaths\": [\"/node_modules\"]\n}",
+			wantValid:    true,
+			wantProblems: []SettingsProblem{},
+		},
+		"success case - unknown fields and a missing version are warnings": {
+			settings:  "{\n  \"contextRoot\": \"/\",\n  \"colour\": \"blue\"\n}",
+			wantValid: true,
+			wantProblems: []SettingsProblem{
+				{1, 1, "schemaVersion", SettingsProblemWarning, "the file has no schemaVersion, run 'cwctl project settings migrate' to upgrade it"},
+				{3, 3, "colour", SettingsProblemWarning, "unknown field, it is not used by Codewind"},
+			},
+		},
+		"success case - fields newer than the file's schema version are warnings": {
+			settings:  "{\n  \"contextRoot\": \"/\",\n  \"maxFileSize\": \"10MB\"\n}",
+			wantValid: true,
+			wantProblems: []SettingsProblem{
+				{1, 1, "schemaVersion", SettingsProblemWarning, "the file has no schemaVersion, run 'cwctl project settings migrate' to upgrade it"},
+				{3, 3, "maxFileSize", SettingsProblemWarning, "the field was added in schema version 1, but the file is version 0, run 'cwctl project settings migrate' to upgrade it"},
+			},
+		},
+		"fail case - values of the wrong type are reported on their line": {
+			settings:  "{\n  \"schemaVersion\": 1,\n  \"internalPort\": 9080,\n  \"isHttps\": \"yes\",\n    \"mavenProfiles\": \"dev\",\n  \"maxFileSizePolicy\": \"ignore\"\n}",
+			wantValid: false,
+			wantProblems: []SettingsProblem{
+				{3, 3, "internalPort", SettingsProblemError, "must be a string"},
+				{4, 3, "isHttps", SettingsProblemError, "must be true or false"},
+				{5, 5, "mavenProfiles", SettingsProblemError, "must be an array of strings"},
+				{6, 3, "maxFileSizePolicy", SettingsProblemError, "must be one of warn, skip or fail, but was \"ignore\""},
+			},
+		},
+		"fail case - invalid ports and duplicate fields": {
+			settings:  "{\n  \"schemaVersion\": 1,\n  \"internalPort\": \"99999\",\n  \"internalPort\": \"9080\"\n}",
+			wantValid: false,
+			wantProblems: []SettingsProblem{
+				{3, 3, "internalPort", SettingsProblemError, "must be a port number between 1 and 65535, but was \"99999\""},
+				{4, 3, "internalPort", SettingsProblemError, "duplicate field, it is already set on line 3"},
+			},
+		},
+		"fail case - a newer schema version": {
+			settings:  "{\"schemaVersion\": 2}",
+			wantValid: false,
+			wantProblems: []SettingsProblem{
+				{1, 2, "schemaVersion", SettingsProblemError, "schema version 2 is newer than this CLI supports (1)"},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			validation := validateSettings([]byte(test.settings))
+			assert.Equal(t, test.wantValid, validation.Valid)
+			assert.Equal(t, test.wantProblems, validation.Problems)
+		})
+	}
+
+	t.Run("fail case - invalid JSON is reported with its position", func(t *testing.T) {
+		validation := validateSettings([]byte("{\n  \"contextRoot\": \"/\",\n  \"internalPort\" \"9080\"\n}"))
+		assert.False(t, validation.Valid)
+		assert.Len(t, validation.Problems, 1)
+		assert.Equal(t, 3, validation.Problems[0].Line)
+		assert.Contains(t, validation.Problems[0].Message, "invalid JSON")
+	})
+}
+
+func TestSetSetting(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "settings_test")
+	defer os.RemoveAll(projectPath)
+	settingsPath := filepath.Join(projectPath, cwSettingsFile)
+
+	t.Run("success case - a missing file is created with the schema version", func(t *testing.T) {
+		err := SetSetting(projectPath, "internalPort", "9080")
+		assert.Nil(t, err)
+		content, _ := ioutil.ReadFile(settingsPath)
+		assert.Equal(t, "{\n  \"schemaVersion\": 1,\n  \"internalPort\": \"9080\"\n}\n", string(content))
+	})
+
+	t.Run("success case - fields keep their order and arrays are comma separated", func(t *testing.T) {
+		ioutil.WriteFile(settingsPath, []byte(`{"schemaVersion": 1, "contextRoot": "/", "colour": "blue", "isHttps": false}`), 0644)
+		assert.Nil(t, SetSetting(projectPath, "mavenProfiles", "dev, test"))
+		assert.Nil(t, SetSetting(projectPath, "isHttps", "true"))
+		content, _ := ioutil.ReadFile(settingsPath)
+		assert.Equal(t, "{\n  \"schemaVersion\": 1,\n  \"contextRoot\": \"/\",\n  \"colour\": \"blue\",\n  \"isHttps\": true,\n  \"mavenProfiles\": [\n    \"dev\",\n    \"test\"\n  ]\n}\n", string(content))
+		assert.Equal(t, []string{"dev", "test"}, retrieveCwSettings(projectPath).MavenProfiles)
+	})
+
+	t.Run("fail case - invalid fields and values are refused", func(t *testing.T) {
+		for name, value := range map[string]string{"colour": "red", "schemaVersion": "3", "internalPort": "http", "isHttps": "maybe"} {
+			err := SetSetting(projectPath, name, value)
+			assert.Equal(t, errOpInvalidOptions, err.Op, name)
+		}
+	})
+}
+
+func TestMigrateSettings(t *testing.T) {
+	t.Run("success case - there is a migration to every schema version", func(t *testing.T) {
+		assert.Len(t, settingsMigrations, CWSettingsSchemaVersion)
+		for _, field := range cwSettingsSchema {
+			assert.True(t, field.Since <= CWSettingsSchemaVersion, field.Name)
+		}
+	})
+
+	t.Run("success case - legacy settings are renamed and converted", func(t *testing.T) {
+		projectPath, _ := ioutil.TempDir("", "settings_test")
+		defer os.RemoveAll(projectPath)
+		ioutil.WriteFile(filepath.Join(projectPath, legacySettingsFile), []byte(`{"internalPort": 9080, "isHttps": "true", "ignoredPaths": "/build", "mavenProfiles": ""}`), 0644)
+
+		migration, err := MigrateSettings(projectPath)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, migration.FromVersion)
+		assert.Equal(t, CWSettingsSchemaVersion, migration.ToVersion)
+		assert.Equal(t, []string{
+			"renamed .mc-settings to .cw-settings",
+			`converted internalPort from 9080 to "9080"`,
+			`converted isHttps from "true" to true`,
+			`converted ignoredPaths from "/build" to ["/build"]`,
+			`converted mavenProfiles from "" to []`,
+			"set schemaVersion to 1",
+		}, migration.Changes)
+
+		validation, _ := ValidateSettings(projectPath)
+		assert.True(t, validation.Valid)
+		assert.Equal(t, []SettingsProblem{}, validation.Problems)
+		assert.False(t, utils.PathExists(filepath.Join(projectPath, legacySettingsFile)))
+	})
+
+	t.Run("success case - current settings are left alone", func(t *testing.T) {
+		projectPath, _ := ioutil.TempDir("", "settings_test")
+		defer os.RemoveAll(projectPath)
+		ioutil.WriteFile(filepath.Join(projectPath, cwSettingsFile), []byte(`{"schemaVersion": 1}`), 0644)
+
+		migration, err := MigrateSettings(projectPath)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{}, migration.Changes)
+		content, _ := ioutil.ReadFile(filepath.Join(projectPath, cwSettingsFile))
+		assert.Equal(t, `{"schemaVersion": 1}`, string(content))
+	})
+}
@@ -13,32 +13,35 @@ package project
 
 import (
 	"bytes"
-	"compress/zlib"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/eclipse/codewind-installer/pkg/config"
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	"github.com/eclipse/codewind-installer/pkg/connections"
 	"github.com/eclipse/codewind-installer/pkg/sechttp"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 type (
 	// CompleteRequest is the request body format for calling the upload complete API
 	CompleteRequest struct {
-		FileList      []string `json:"fileList"`
-		DirectoryList []string `json:"directoryList"`
-		ModifiedList  []string `json:"modifiedList"`
-		TimeStamp     int64    `json:"timeStamp"`
+		FileList      []string      `json:"fileList"`
+		DirectoryList []string      `json:"directoryList"`
+		ModifiedList  []string      `json:"modifiedList"`
+		DeletedList   []string      `json:"deletedList"`
+		RenamedList   []RenamedFile `json:"renamedList"`
+		TimeStamp     int64         `json:"timeStamp"`
 	}
 
 	// FileUploadMsg is the message sent on uploading a file
@@ -54,6 +57,15 @@ type (
 		FilePath   string `json:"filePath"`
 		Status     string `json:"status"`
 		StatusCode int    `json:"statusCode"`
+		Outcome    string `json:"outcome"`
+		Attempts   int    `json:"attempts"`
+	}
+
+	// RenamedFile is a file that moved within the project, found by matching its content hash
+	RenamedFile struct {
+		From  string        `json:"from"`
+		To    string        `json:"to"`
+		entry manifestEntry // the state of the file, which is unchanged by the move
 	}
 
 	// SyncResponse is the status of the file syncing
@@ -61,14 +73,17 @@ type (
 		Status        string         `json:"status"`
 		StatusCode    int            `json:"statusCode"`
 		UploadedFiles []UploadedFile `json:"uploadedFiles"`
+		DeletedFiles  []string       `json:"deletedFiles"`
+		RenamedFiles  []RenamedFile  `json:"renamedFiles"`
 	}
 
 	// walkerInfo is the input struct to the walker function
 	walkerInfo struct {
-		Path         string   // the path of the current file
-		os.FileInfo           // the FileInfo of the current file
-		IgnoredPaths []string // paths to ignore
-		LastSync     int64    // last sync time
+		Path        string         // the path of the current file
+		os.FileInfo                // the FileInfo of the current file
+		Ignore      *ignoreMatcher // decides which paths are not synced
+		LastSync    int64          // last sync time, used when there is no manifest
+		Manifest    *syncManifest  // manifest from the last sync, nil if unknown
 	}
 
 	// SyncInfo contains the information from a project sync
@@ -76,9 +91,27 @@ type (
 		fileList         []string
 		directoryList    []string
 		modifiedList     []string
+		deletedList      []string
+		renamedList      []RenamedFile
+		manifest         *syncManifest
 		UploadedFileList []UploadedFile
 	}
 
+	// syncPlan is what a sync needs to upload, found by walking the project
+	syncPlan struct {
+		fileList      []string
+		directoryList []string
+		modifiedList  []string
+		deletedList   []string
+		renamedList   []RenamedFile
+		excludedList  []ExcludedPath
+		uploads       []fileUpload
+		skippedFiles  []UploadedFile
+		refErrors     []string // the references in .cw-refpaths.json that could not be synced
+		limits        uploadLimits
+		manifest      *syncManifest // the unchanged files, uploaded files are added once PFE accepts them
+	}
+
 	// refPath is a referenced file path to sync
 	refPath struct {
 		From string `json:"from"`
@@ -93,26 +126,26 @@ type (
 
 // SyncProject syncs a project with its remote connection
 func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
-	var currentSyncTime = time.Now().UnixNano() / 1000000
 	projectPath := strings.TrimSpace(c.String("path"))
 	projectID := strings.TrimSpace(c.String("id"))
 	synctime := int64(c.Int("time"))
+	options := syncOptionsFromContext(c)
 
 	conID, projErr := GetConnectionID(projectID)
-
 	if projErr != nil {
 		return nil, projErr
 	}
-
-	conInfo, conInfoErr := connections.GetConnectionByID(conID)
-	if conInfoErr != nil {
-		return nil, &ProjectError{errOpConNotFound, conInfoErr, conInfoErr.Desc}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
 	}
 
-	conURL, conURLErr := config.PFEOriginFromConnection(conInfo)
-	if conURLErr != nil {
-		return nil, &ProjectError{errOpConNotFound, conURLErr.Err, conURLErr.Desc}
-	}
+	return syncProject(projectPath, projectID, conInfo, conURL, synctime, options)
+}
+
+// syncProject uploads the changes to a project and tells PFE the upload is complete
+func syncProject(projectPath string, projectID string, conInfo *connections.Connection, conURL string, synctime int64, options SyncOptions) (*SyncResponse, *ProjectError) {
+	var currentSyncTime = time.Now().UnixNano() / 1000000
 
 	// if local path doesn't exist but is equal to the locOnDisk, the directory has likely been deleted
 	// emit this message to the UI socket by calling the PFE /missingLocalDir API
@@ -137,19 +170,41 @@ func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
 		return nil, &ProjectError{errBadPath, newErr, newErr.Error()}
 	}
 
+	// Compare against the manifest of the last sync, if there was one
+	previousManifest, manifestErr := loadSyncManifest(projectID)
+	if manifestErr != nil {
+		logr.Warnf("Unable to read the sync manifest for project %v, falling back to the last sync time: %v", projectID, manifestErr.Desc)
+	}
+
 	// Sync all the necessary project files
-	syncInfo, syncErr := syncFiles(&http.Client{}, projectPath, projectID, conURL, synctime, conInfo)
+	syncInfo, syncErr := syncFiles(&http.Client{}, projectPath, projectID, conURL, synctime, previousManifest, options, conInfo)
+	if syncInfo == nil {
+		return nil, syncErr
+	}
 
 	// Complete the upload
 	completeRequest := CompleteRequest{
 		FileList:      syncInfo.fileList,
 		DirectoryList: syncInfo.directoryList,
 		ModifiedList:  syncInfo.modifiedList,
+		DeletedList:   syncInfo.deletedList,
+		RenamedList:   syncInfo.renamedList,
 		TimeStamp:     currentSyncTime,
 	}
 	completeStatus, completeStatusCode := completeUpload(&http.Client{}, projectID, completeRequest, conInfo, conURL)
+
+	// Only record what PFE has once it has accepted the upload
+	if completeStatusCode == http.StatusOK {
+		saveErr := saveSyncManifest(projectID, syncInfo.manifest)
+		if saveErr != nil {
+			logr.Warnf("Unable to save the sync manifest for project %v: %v", projectID, saveErr.Desc)
+		}
+	}
+
 	response := SyncResponse{
 		UploadedFiles: syncInfo.UploadedFileList,
+		DeletedFiles:  syncInfo.deletedList,
+		RenamedFiles:  syncInfo.renamedList,
 		Status:        completeStatus,
 		StatusCode:    completeStatusCode,
 	}
@@ -157,119 +212,266 @@ func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
 	return &response, syncErr
 }
 
-func syncFiles(client utils.HTTPClient, projectPath string, projectID string, conURL string, synctime int64, connection *connections.Connection) (*SyncInfo, *ProjectError) {
-	var fileList []string
-	var directoryList []string
-	var modifiedList []string
-	var uploadedFiles []UploadedFile
+// syncFiles uploads the files in a project that have changed since the last sync.
+// The project and its referenced files are walked first, then the changed files are uploaded in parallel.
+func syncFiles(client utils.HTTPClient, projectPath string, projectID string, conURL string, synctime int64, previousManifest *syncManifest, options SyncOptions, connection *connections.Connection) (*SyncInfo, *ProjectError) {
+	plan, planErr := planSync(projectPath, synctime, previousManifest, options)
+	if plan == nil {
+		return nil, planErr
+	}
 
 	projectUploadURL := conURL + "/api/v1/projects/" + projectID + "/upload"
+	manifest := plan.manifest
+	uploads := plan.uploads
+	modifiedList := plan.modifiedList
+	deletedList := plan.deletedList
+	renamedList := plan.renamedList
+	var uploadedFiles []UploadedFile
+
+	var capabilities []string
+	if len(uploads) > 0 {
+		capabilities, _ = apiroutes.GetPFECapabilities(connection, conURL, client)
+	}
+
+	// PFE can move renamed files itself, otherwise they are deleted and uploaded again
+	if len(renamedList) > 0 {
+		if apiroutes.HasCapability(capabilities, apiroutes.CapabilityRenameFiles) {
+			uploads, modifiedList = withoutRenamedFiles(uploads, modifiedList, renamedList)
+			for _, renamed := range renamedList {
+				manifest.Files[renamed.To] = renamed.entry
+			}
+		} else {
+			for _, renamed := range renamedList {
+				deletedList = append(deletedList, renamed.From)
+			}
+			sort.Strings(deletedList)
+			renamedList = []RenamedFile{}
+		}
+	}
+
+	// upload the modified files as a single archive if PFE supports it, otherwise one at a time
+	limiter := newRateLimiter(plan.limits.rateLimit)
+	var results []uploadResult
+	batchUploaded := false
+	if len(uploads) > 0 && apiroutes.HasCapability(capabilities, apiroutes.CapabilityBatchUpload) {
+		results, batchUploaded = uploadBatch(client, projectUploadURL+"/batch", uploads, limiter, connection)
+	}
+	if !batchUploaded {
+		results = uploadFiles(client, projectUploadURL, uploads, options, limiter, connection)
+	}
+
+	// only record a file as synced once PFE has accepted it
+	for _, result := range results {
+		uploadedFiles = append(uploadedFiles, result.UploadedFile)
+		if result.accepted {
+			manifest.Files[result.FilePath] = result.entry
+		}
+	}
+	uploadedFiles = append(uploadedFiles, plan.skippedFiles...)
+
+	syncInfo := SyncInfo{
+		fileList:         plan.fileList,
+		directoryList:    plan.directoryList,
+		modifiedList:     modifiedList,
+		deletedList:      deletedList,
+		renamedList:      renamedList,
+		manifest:         manifest,
+		UploadedFileList: uploadedFiles,
+	}
+	return &syncInfo, planErr
+}
+
+// withoutRenamedFiles removes the files PFE will move itself from the uploads and modified list
+func withoutRenamedFiles(uploads []fileUpload, modifiedList []string, renamedList []RenamedFile) ([]fileUpload, []string) {
+	renamed := map[string]bool{}
+	for _, file := range renamedList {
+		renamed[file.To] = true
+	}
+	remainingUploads := []fileUpload{}
+	for _, upload := range uploads {
+		if !renamed[upload.RelativePath] {
+			remainingUploads = append(remainingUploads, upload)
+		}
+	}
+	remainingModified := []string{}
+	for _, relativePath := range modifiedList {
+		if !renamed[relativePath] {
+			remainingModified = append(remainingModified, relativePath)
+		}
+	}
+	return remainingUploads, remainingModified
+}
+
+// planSync walks a project and its referenced files to find what needs uploading, without contacting PFE.
+// Changes are found by comparing against previousManifest, or by comparing modification
+// times against synctime if the project has no manifest yet.
+func planSync(projectPath string, synctime int64, previousManifest *syncManifest, options SyncOptions) (*syncPlan, *ProjectError) {
+	limits, limitsErr := resolveUploadLimits(projectPath, options)
+	if limitsErr != nil {
+		return nil, limitsErr
+	}
+
+	plan := syncPlan{manifest: newSyncManifest(), limits: limits}
+	modifiedEntries := map[string]manifestEntry{}
+	tooLarge := []string{}
+
+	// skipFile records that a file was not uploaded, keeping whatever was last synced
+	skipFile := func(relativePath string, reason string, outcome string, previousManifest *syncManifest) {
+		plan.skippedFiles = append(plan.skippedFiles, UploadedFile{
+			FilePath: relativePath,
+			Status:   reason,
+			Outcome:  outcome,
+		})
+		if previousManifest != nil {
+			if previousEntry, found := previousManifest.Files[relativePath]; found {
+				plan.manifest.Files[relativePath] = previousEntry
+			}
+		}
+	}
 
 	refPathsChanged := false
 
 	// define a walker function
 	walker := func(path string, info walkerInfo, err error) error {
-		if err != nil {
+		// If it is the top level directory ignore it, unless it cannot be read at all
+		if path == projectPath {
 			return err
-			// TODO - How to handle *some* files being unreadable
 		}
 
-		// If it is the top level directory ignore it
-		if path == projectPath {
+		projectRelativePath, relErr := filepath.Rel(projectPath, path)
+		if relErr != nil {
+			return relErr
+		}
+		// use ToSlash to try and get both Windows and *NIX paths to be *NIX for pfe
+		relativePath := filepath.ToSlash(projectRelativePath)
+
+		// Skip a path that cannot be read, keeping whatever was last synced from it
+		if err != nil {
+			skipFile(relativePath, err.Error(), UploadOutcomeSkippedUnreadable, info.Manifest)
+			if info.FileInfo == nil || !info.IsDir() {
+				return nil
+			}
+			if info.Manifest != nil {
+				for previousPath, previousEntry := range info.Manifest.Files {
+					if strings.HasPrefix(previousPath, relativePath+"/") {
+						plan.manifest.Files[previousPath] = previousEntry
+					}
+				}
+			}
+			return filepath.SkipDir
+		}
+
+		if rule := info.Ignore.match(relativePath, info.IsDir()); rule != nil {
+			plan.excludedList = append(plan.excludedList, ExcludedPath{relativePath, rule.Pattern, rule.Source})
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		// use ToSlash to try and get both Windows and *NIX paths to be *NIX for pfe
-		relativePath := filepath.ToSlash(path[(len(projectPath) + 1):])
+		if info.IsDir() {
+			plan.directoryList = append(plan.directoryList, relativePath)
+			return nil
+		}
+
+		// Create list of all files for a project
+		plan.fileList = append(plan.fileList, relativePath)
 
-		if !info.IsDir() {
-			shouldIgnore := ignoreFileOrDirectory(relativePath, false, info.IgnoredPaths)
-			if shouldIgnore {
+		// A file with the size, mode and modification time it was last synced with is unchanged, so is not read again
+		if info.Manifest != nil {
+			if previousEntry, found := info.Manifest.Files[relativePath]; found && previousEntry.matchesInfo(info.FileInfo) {
+				plan.manifest.Files[relativePath] = previousEntry
 				return nil
 			}
-			// Create list of all files for a project
-			fileList = append(fileList, relativePath)
+		}
 
+		// Without a manifest, a file last modified before the last sync is unchanged
+		modifiedSinceSync := true
+		if info.Manifest == nil {
 			// get time file was modified in milliseconds since epoch
 			modifiedmillis := info.ModTime().UnixNano() / 1000000
+			modifiedSinceSync = modifiedmillis > info.LastSync
+		}
 
-			fileUploadBody := FileUploadMsg{
-				IsDirectory:  info.IsDir(),
-				Mode:         uint(info.Mode().Perm()),
-				RelativePath: relativePath,
-				Message:      "",
+		// A file over the maximum size is skipped, or fails the sync, without being read
+		reason, withinLimit := limits.checkFileSize(relativePath, info.Size())
+		if !withinLimit && limits.fileSizePolicy != FileSizePolicyWarn {
+			switch {
+			case !modifiedSinceSync:
+				// recorded without a hash, it is only read once it changes and is within the limit
+				plan.manifest.Files[relativePath] = newManifestEntry(info.FileInfo, nil)
+			case limits.fileSizePolicy == FileSizePolicySkip:
+				skipFile(relativePath, reason, UploadOutcomeSkippedTooLarge, info.Manifest)
+			default:
+				tooLarge = append(tooLarge, reason)
 			}
+			return nil
+		}
 
-			// Has this file been modified since last sync
-			if modifiedmillis > info.LastSync {
-				fileContent, err := ioutil.ReadFile(info.Path)
-				// Skip this file if there is an error reading it.
-				if err != nil {
-					return nil
-				}
-				// Create list of all modfied files
-				modifiedList = append(modifiedList, relativePath)
-
-				var buffer bytes.Buffer
-				zWriter := zlib.NewWriter(&buffer)
-				zWriter.Write([]byte(fileContent))
-
-				zWriter.Close()
-				encoded := base64.StdEncoding.EncodeToString(buffer.Bytes())
-				fileUploadBody.Message = encoded
-
-				buf := new(bytes.Buffer)
-				json.NewEncoder(buf).Encode(fileUploadBody)
-
-				// TODO - How do we handle partial success?
-				request, err := http.NewRequest("PUT", projectUploadURL, bytes.NewReader(buf.Bytes()))
-				request.Header.Set("Content-Type", "application/json")
-				resp, httpSecError := sechttp.DispatchHTTPRequest(client, request, connection)
-				uploadedFiles = append(uploadedFiles, UploadedFile{
-					FilePath:   relativePath,
-					Status:     resp.Status,
-					StatusCode: resp.StatusCode,
-				})
-				if httpSecError != nil {
-					return nil
-				}
-				defer resp.Body.Close()
+		entry, err := hashFile(info.Path, info.FileInfo)
+		// Skip this file if there is an error reading it, keeping whatever was last synced
+		if err != nil {
+			skipFile(relativePath, err.Error(), UploadOutcomeSkippedUnreadable, info.Manifest)
+			return nil
+		}
 
-				// if this file changed, it should force referenced files to re-sync
-				if relativePath == ".cw-refpaths.json" {
-					refPathsChanged = true
-				}
-			}
-		} else {
-			shouldIgnore := ignoreFileOrDirectory(relativePath, true, info.IgnoredPaths)
-			if shouldIgnore {
-				return filepath.SkipDir
-			}
-			directoryList = append(directoryList, relativePath)
+		// Has this file been modified since last sync
+		fileChanged := modifiedSinceSync
+		if info.Manifest != nil {
+			fileChanged = info.Manifest.hasChanged(relativePath, entry)
+		}
+
+		if !fileChanged {
+			plan.manifest.Files[relativePath] = entry
+			return nil
+		}
+
+		if !withinLimit {
+			logr.Warnf("Uploading %v", reason)
+		}
+
+		// Create list of all modfied files, they are uploaded once the walk is complete
+		plan.modifiedList = append(plan.modifiedList, relativePath)
+		plan.uploads = append(plan.uploads, fileUpload{relativePath, info.Path, info.FileInfo})
+		modifiedEntries[relativePath] = entry
+
+		// if this file changed, it should force referenced files to re-sync
+		if relativePath == ".cw-refpaths.json" {
+			refPathsChanged = true
 		}
 		return nil
 	}
 
-	// read the ignored and referenced paths into lists
-	cwSettingsIgnoredPathsList := retrieveIgnoredPathsList(projectPath)
+	// read the ignore rules and referenced paths
+	projectIgnore := newProjectIgnoreMatcher(projectPath, options)
 	cwRefPathsList := retrieveRefPathsList(projectPath)
 
-	// initialize a combined list, prime it with ignored paths from .cw-settings
-	// then append with referenced "To" paths
-	cwCombinedIgnoredPathsList := append([]string{}, cwSettingsIgnoredPathsList...)
+	// expand the references into the files and directories they sync
+	refSources := []refSource{}
 	for _, refPath := range cwRefPathsList {
-		cwCombinedIgnoredPathsList = append(cwCombinedIgnoredPathsList, refPath.To)
+		sources, err := expandRefPath(projectPath, refPath)
+		if err != nil {
+			plan.refErrors = append(plan.refErrors, fmt.Sprintf("invalid file reference %q: %v", refPath.From, err))
+			continue
+		}
+		refSources = append(refSources, sources...)
+	}
+
+	// files in the project that are also the target of a reference should not be synced
+	refTargets := []string{}
+	for _, source := range refSources {
+		refTargets = append(refTargets, escapeIgnorePattern(source.To))
 	}
+	projectAndRefsIgnore := projectIgnore.withRules(refTargets, ignoreSourceRefPaths)
 
 	// first sync files that are physically in the project
 	err := filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
-		// use combined ignored paths here, files in the project that
-		// are also the target of a reference should not be synced
 		wInfo := walkerInfo{
 			path,
 			info,
-			cwCombinedIgnoredPathsList,
+			projectAndRefsIgnore,
 			synctime,
+			previousManifest,
 		}
 		return walker(path, wInfo, err)
 	})
@@ -278,47 +480,88 @@ func syncFiles(client utils.HTTPClient, projectPath string, projectID string, co
 		return nil, &ProjectError{errOpSync, errors.New(text), text}
 	}
 
-	errText := ""
+	lastSync := synctime
+	lastManifest := previousManifest
+	// force re-sync if .cw-refpaths.json itself was changed
+	if refPathsChanged {
+		lastSync = 0
+		lastManifest = nil
+	}
 
-	// then sync referenced file paths
-	for _, refPath := range cwRefPathsList {
+	// then sync referenced file paths, the first reference to a path wins if several map to it
+	claimed := map[string]string{}
+	claimedDirectories := map[string]bool{}
+	syncRef := func(from string, to string, info os.FileInfo) error {
+		if info.IsDir() {
+			if claimedDirectories[to] {
+				return nil
+			}
+			claimedDirectories[to] = true
+		} else if previous, found := claimed[to]; found {
+			if previous != from {
+				plan.refErrors = append(plan.refErrors, fmt.Sprintf("conflicting file references %q and %q both sync to %q", previous, from, to))
+			}
+			return nil
+		} else {
+			claimed[to] = from
+		}
 
-		// get From path and resolve to absolute if needed
-		from := refPath.From
-		if !filepath.IsAbs(from) {
-			from = filepath.Join(projectPath, from)
+		// "To" path is relative to the project
+		wInfo := walkerInfo{
+			from,
+			info,
+			projectIgnore,
+			lastSync,
+			lastManifest,
 		}
+		return walker(filepath.Join(projectPath, filepath.FromSlash(to)), wInfo, nil)
+	}
 
-		// get info on the referenced file; skip invalid paths
-		info, err := os.Stat(from)
-		if err != nil || info.IsDir() {
-			text := fmt.Sprintf("invalid file reference %q: %v\n", from, err)
-			errText += text
+	for _, source := range refSources {
+		if !source.IsDir() {
+			syncRef(source.From, source.To, source.FileInfo)
 			continue
 		}
 
-		lastSync := synctime
-		// force re-sync if .cw-refpaths.json itself was changed
-		if refPathsChanged {
-			lastSync = 0
+		// referenced directories are synced recursively, using the project's ignore rules
+		err := filepath.Walk(source.From, func(filePath string, info os.FileInfo, err error) error {
+			relativePath, relErr := filepath.Rel(source.From, filePath)
+			if relErr != nil {
+				return relErr
+			}
+			to := path.Join(source.To, filepath.ToSlash(relativePath))
+			// a path that cannot be read is reported and skipped, as it is in the project
+			if err != nil {
+				wInfo := walkerInfo{filePath, info, projectIgnore, lastSync, lastManifest}
+				return walker(filepath.Join(projectPath, filepath.FromSlash(to)), wInfo, err)
+			}
+			return syncRef(filePath, to, info)
+		})
+		if err != nil {
+			plan.refErrors = append(plan.refErrors, fmt.Sprintf("invalid directory reference %q: %v", source.From, err))
 		}
+	}
 
-		// now pass it to the walker function
-		wInfo := walkerInfo{
-			from,
-			info,
-			cwSettingsIgnoredPathsList,
-			lastSync,
-		}
-		// "To" path is relative to the project
-		walker(filepath.Join(projectPath, refPath.To), wInfo, nil)
+	if len(tooLarge) > 0 {
+		text := "files are larger than the maximum file size: " + strings.Join(tooLarge, "; ")
+		return nil, &ProjectError{errOpSyncLimit, errors.New(text), text}
 	}
 
-	if errText != "" {
-		return &SyncInfo{fileList, directoryList, modifiedList, uploadedFiles}, &ProjectError{errOpSyncRef, errors.New(errText), errText}
+	// files that were synced before but are not in the project now have been deleted or renamed
+	current := newSyncManifest()
+	for relativePath, entry := range plan.manifest.Files {
+		current.Files[relativePath] = entry
+	}
+	for relativePath, entry := range modifiedEntries {
+		current.Files[relativePath] = entry
 	}
+	plan.renamedList, plan.deletedList = findRenames(previousManifest, plan.modifiedList, modifiedEntries, current.deletedSince(previousManifest))
 
-	return &SyncInfo{fileList, directoryList, modifiedList, uploadedFiles}, nil
+	if len(plan.refErrors) > 0 {
+		errText := strings.Join(plan.refErrors, "\n") + "\n"
+		return &plan, &ProjectError{errOpSyncRef, errors.New(errText), errText}
+	}
+	return &plan, nil
 }
 
 func completeUpload(client utils.HTTPClient, projectID string, completeRequest CompleteRequest, conInfo *connections.Connection, conURL string) (string, int) {
@@ -343,17 +586,24 @@ func completeUpload(client utils.HTTPClient, projectID string, completeRequest C
 
 // Retrieve the ignoredPaths list from a .cw-settings file
 func retrieveIgnoredPathsList(projectPath string) []string {
-	cwSettingsPath := filepath.Join(projectPath, ".cw-settings")
-	var cwSettingsIgnoredPathsList []string
-	if _, err := os.Stat(cwSettingsPath); !os.IsNotExist(err) {
-		plan, _ := ioutil.ReadFile(cwSettingsPath)
-		var cwSettingsJSON CWSettings
+	return retrieveCwSettings(projectPath).IgnoredPaths
+}
+
+// Retrieve the settings from a .cw-settings file, a missing or invalid file gives empty settings
+func retrieveCwSettings(projectPath string) CWSettings {
+	var cwSettingsJSON CWSettings
+	plan, err := ioutil.ReadFile(filepath.Join(projectPath, cwSettingsFile))
+	if os.IsNotExist(err) {
+		return cwSettingsJSON
+	}
+	if err == nil {
 		err = json.Unmarshal(plan, &cwSettingsJSON)
-		if err == nil {
-			cwSettingsIgnoredPathsList = cwSettingsJSON.IgnoredPaths
-		}
 	}
-	return cwSettingsIgnoredPathsList
+	if err != nil {
+		logr.Warnf("Ignoring the settings in %v, run 'cwctl project settings validate' to find the problem: %v", cwSettingsFile, err)
+		return CWSettings{}
+	}
+	return cwSettingsJSON
 }
 
 // Retrieve the refPaths list from a .cw-refpaths.json file
@@ -371,72 +621,6 @@ func retrieveRefPathsList(projectPath string) []refPath {
 	return cwRefPathsList
 }
 
-func ignoreFileOrDirectory(name string, isDir bool, cwSettingsIgnoredPathsList []string) bool {
-	// List of files that will not be sent to PFE
-	ignoredFiles := []string{
-		".DS_Store",
-		"*.swp",
-		"*.swx",
-		"Jenkinsfile",
-		".cfignore",
-		"localm2cache.zip",
-		"libertyrepocache.zip",
-		"run-dev",
-		"run-debug",
-		"manifest.yml",
-		"idt.js",
-		".bluemix",
-		".build-ubuntu",
-		".yo-rc.json",
-		"*.iml",
-		".project",
-		".classpath",
-		".options",
-	}
-
-	// List of directories that will not be sent to PFE
-	ignoredDirectories := []string{
-		".project",
-		"node_modules*",
-		".git*",
-		"load-test*",
-		".settings",
-		"Dockerfile-tools",
-		"target",
-		"mc-target",
-		".m2",
-		"debian",
-		".bluemix",
-		"terraform",
-		".build-ubuntu",
-		".idea",
-		".vscode",
-	}
-
-	ignoredList := ignoredFiles
-	if isDir {
-		ignoredList = ignoredDirectories
-	}
-
-	if len(cwSettingsIgnoredPathsList) > 0 {
-		ignoredList = append(ignoredList, cwSettingsIgnoredPathsList...)
-	}
-
-	isFileInIgnoredList := false
-	for _, fileName := range ignoredList {
-		fileName = filepath.Clean(fileName)
-		matched, err := filepath.Match(fileName, name)
-		if err != nil {
-			return false
-		}
-		if matched {
-			isFileInIgnoredList = true
-			break
-		}
-	}
-	return isFileInIgnoredList
-}
-
 // handleMissingProjectDir : Respond to a local project dir not existing
 func handleMissingProjectDir(httpClient utils.HTTPClient, connection *connections.Connection, url, projectID string) *ProjectError {
 	req, requestErr := http.NewRequest("POST", url+"/api/v1/projects/"+projectID+"/missingLocalDir", nil)
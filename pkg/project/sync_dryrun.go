@@ -0,0 +1,102 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// SyncDryRunResponse : What a sync would upload, worked out without contacting PFE
+type SyncDryRunResponse struct {
+	FileList      []string       `json:"fileList"`
+	DirectoryList []string       `json:"directoryList"`
+	ModifiedList  []string       `json:"modifiedList"`
+	TotalBytes    int64          `json:"totalBytes"`
+	SkippedFiles  []UploadedFile `json:"skippedFiles"`
+	RefErrors     []string       `json:"refErrors"`
+	ExcludedList  []ExcludedPath `json:"excludedList,omitempty"`
+}
+
+// SyncProjectDryRun : Walk a project as a sync would, without uploading anything.
+// If the explain flag is set the paths left out of the sync are listed with the rule that excluded them.
+func SyncProjectDryRun(c *cli.Context) (*SyncDryRunResponse, *ProjectError) {
+	projectPath := strings.TrimSpace(c.String("path"))
+	projectID := strings.TrimSpace(c.String("id"))
+	synctime := int64(c.Int("time"))
+	options := syncOptionsFromContext(c)
+
+	if !utils.PathExists(projectPath) {
+		err := errors.New(textProjectPathDoesNotExist)
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+
+	previousManifest, manifestErr := loadSyncManifest(projectID)
+	if manifestErr != nil {
+		logr.Warnf("Unable to read the sync manifest for project %v, falling back to the last sync time: %v", projectID, manifestErr.Desc)
+	}
+
+	return dryRunSync(projectPath, synctime, previousManifest, options, c.Bool("explain"))
+}
+
+// BindProjectDryRun : Walk a project as a bind would, without creating it on PFE or uploading anything
+func BindProjectDryRun(c *cli.Context) (*SyncDryRunResponse, *ProjectError) {
+	projectPath := strings.TrimSpace(c.String("path"))
+	options := syncOptionsFromContext(c)
+
+	if !utils.PathExists(projectPath) {
+		err := errors.New(textProjectPathDoesNotExist)
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+
+	// a bind uploads every file, as there is nothing on PFE to compare against
+	return dryRunSync(projectPath, 0, nil, options, c.Bool("explain"))
+}
+
+// dryRunSync plans a sync and reports what it would upload. References that could not be
+// synced are reported in RefErrors with the rest of the plan, rather than as an error.
+func dryRunSync(projectPath string, synctime int64, previousManifest *syncManifest, options SyncOptions, explain bool) (*SyncDryRunResponse, *ProjectError) {
+	plan, planErr := planSync(projectPath, synctime, previousManifest, options)
+	if plan == nil {
+		return nil, planErr
+	}
+
+	response := SyncDryRunResponse{
+		FileList:      emptyIfNil(plan.fileList),
+		DirectoryList: emptyIfNil(plan.directoryList),
+		ModifiedList:  emptyIfNil(plan.modifiedList),
+		SkippedFiles:  plan.skippedFiles,
+		RefErrors:     emptyIfNil(plan.refErrors),
+	}
+	if response.SkippedFiles == nil {
+		response.SkippedFiles = []UploadedFile{}
+	}
+	for _, upload := range plan.uploads {
+		response.TotalBytes += upload.Size()
+	}
+	if explain {
+		response.ExcludedList = plan.excludedList
+	}
+	return &response, nil
+}
+
+// emptyIfNil makes sure lists are written to JSON as [] rather than null
+func emptyIfNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,113 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestSyncProjectDryRun(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "dryrun_test")
+	defer os.RemoveAll(projectPath)
+
+	os.Mkdir(filepath.Join(projectPath, "node_modules"), 0777)
+	ioutil.WriteFile(filepath.Join(projectPath, "node_modules", "module.js"), []byte{}, 0644)
+	os.Mkdir(filepath.Join(projectPath, "src"), 0777)
+	ioutil.WriteFile(filepath.Join(projectPath, "src", "app.js"), []byte("console.log()"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, "debug.log"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, ".gitignore"), []byte("*.log\n"), 0644)
+
+	newContext := func(explain bool, gitignore bool) *cli.Context {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("path", projectPath, "")
+		set.String("id", "dryRunTestID", "")
+		set.Bool("explain", explain, "")
+		set.Bool("gitignore", gitignore, "")
+		return cli.NewContext(nil, set, nil)
+	}
+
+	t.Run("success case - lists the files that would be uploaded", func(t *testing.T) {
+		response, err := SyncProjectDryRun(newContext(false, false))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{".gitignore", "debug.log", "src/app.js"}, response.FileList)
+		assert.Equal(t, []string{"src"}, response.DirectoryList)
+		assert.Equal(t, []string{".gitignore", "debug.log", "src/app.js"}, response.ModifiedList)
+		assert.Equal(t, int64(len("*.log\n")+len("console.log()")), response.TotalBytes)
+		assert.Nil(t, response.ExcludedList)
+	})
+
+	t.Run("success case - explains why paths were excluded", func(t *testing.T) {
+		response, err := SyncProjectDryRun(newContext(true, true))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{".gitignore", "src/app.js"}, response.ModifiedList)
+		assert.Equal(t, []ExcludedPath{
+			{Path: "debug.log", Rule: "*.log", Source: ".gitignore:1"},
+			{Path: "node_modules", Rule: "node_modules*", Source: ignoreSourceBuiltIn},
+		}, response.ExcludedList)
+	})
+
+	t.Run("fail case - project path does not exist", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("path", filepath.Join(projectPath, "missing"), "")
+		set.String("id", "dryRunTestID", "")
+		_, err := SyncProjectDryRun(cli.NewContext(nil, set, nil))
+		assert.Equal(t, errBadPath, err.Op)
+	})
+}
+
+func TestBindProjectDryRun(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "dryrun_test")
+	defer os.RemoveAll(projectPath)
+
+	ioutil.WriteFile(filepath.Join(projectPath, "app.js"), []byte("console.log()"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, ".DS_Store"), []byte{}, 0644)
+
+	t.Run("success case - every file that is not ignored would be uploaded", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("path", projectPath, "")
+		response, err := BindProjectDryRun(cli.NewContext(nil, set, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app.js"}, response.FileList)
+		assert.Equal(t, []string{}, response.DirectoryList)
+		assert.Equal(t, []string{"app.js"}, response.ModifiedList)
+		assert.Equal(t, int64(len("console.log()")), response.TotalBytes)
+		assert.Equal(t, []string{}, response.RefErrors)
+	})
+
+	t.Run("success case - invalid references are reported with the rest of the plan", func(t *testing.T) {
+		refPathsFile := filepath.Join(projectPath, ".cw-refpaths.json")
+		ioutil.WriteFile(refPathsFile, []byte(`{"refPaths":[{"from":"missing.txt","to":"copy.txt"}]}`), 0644)
+		defer os.Remove(refPathsFile)
+
+		set := flag.NewFlagSet("tests", 0)
+		set.String("path", projectPath, "")
+		response, err := BindProjectDryRun(cli.NewContext(nil, set, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{".cw-refpaths.json", "app.js"}, response.ModifiedList)
+		assert.Len(t, response.RefErrors, 1)
+		assert.Contains(t, response.RefErrors[0], `invalid file reference "missing.txt"`)
+	})
+
+	t.Run("fail case - project path does not exist", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("path", filepath.Join(projectPath, "missing"), "")
+		_, err := BindProjectDryRun(cli.NewContext(nil, set, nil))
+		assert.Equal(t, errBadPath, err.Op)
+	})
+}
@@ -0,0 +1,188 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// What a sync does with a modified file larger than the maximum file size
+const (
+	FileSizePolicyWarn = "warn" // upload the file, logging a warning
+	FileSizePolicySkip = "skip" // do not upload the file, reporting it as skipped
+	FileSizePolicyFail = "fail" // do not sync the project at all
+)
+
+// byteSizeUnits are the suffixes accepted by parseByteSize, longest first so "MB" is not read as "B"
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"B", 1},
+}
+
+type (
+	// uploadLimits are the size and bandwidth limits for a sync, from the flags or the project's .cw-settings
+	uploadLimits struct {
+		maxFileSize    int64  // the largest modified file that is uploaded without applying fileSizePolicy, 0 for no limit
+		fileSizePolicy string // one of the FileSizePolicy constants
+		rateLimit      int64  // the most bytes uploaded per second, 0 for no limit
+	}
+
+	// rateLimiter limits the total rate that bytes are read by every reader it wraps
+	rateLimiter struct {
+		bytesPerSecond int64
+		mutex          sync.Mutex
+		start          time.Time
+		total          int64
+	}
+
+	// rateLimitedReader is a reader whose reads are limited by a shared rateLimiter
+	rateLimitedReader struct {
+		reader  io.Reader
+		limiter *rateLimiter
+	}
+)
+
+// resolveUploadLimits works out the limits for a sync, flags take precedence over .cw-settings
+func resolveUploadLimits(projectPath string, options SyncOptions) (uploadLimits, *ProjectError) {
+	cwSettings := retrieveCwSettings(projectPath)
+	limits := uploadLimits{}
+
+	maxFileSize := firstNonEmpty(options.MaxFileSize, cwSettings.MaxFileSize)
+	size, err := parseByteSize(maxFileSize)
+	if err != nil {
+		text := fmt.Sprintf("invalid maximum file size %q: %v", maxFileSize, err)
+		return limits, &ProjectError{errOpInvalidOptions, errors.New(text), text}
+	}
+	limits.maxFileSize = size
+
+	limits.fileSizePolicy = strings.ToLower(firstNonEmpty(options.MaxFileSizePolicy, cwSettings.MaxFileSizePolicy, FileSizePolicyWarn))
+	switch limits.fileSizePolicy {
+	case FileSizePolicyWarn, FileSizePolicySkip, FileSizePolicyFail:
+	default:
+		text := fmt.Sprintf("invalid maximum file size policy %q, must be one of %v, %v or %v", limits.fileSizePolicy, FileSizePolicyWarn, FileSizePolicySkip, FileSizePolicyFail)
+		return limits, &ProjectError{errOpInvalidOptions, errors.New(text), text}
+	}
+
+	rateLimit := firstNonEmpty(options.RateLimit, cwSettings.UploadRateLimit)
+	rate, err := parseByteSize(rateLimit)
+	if err != nil {
+		text := fmt.Sprintf("invalid upload rate limit %q: %v", rateLimit, err)
+		return limits, &ProjectError{errOpInvalidOptions, errors.New(text), text}
+	}
+	limits.rateLimit = rate
+	return limits, nil
+}
+
+// firstNonEmpty returns the first value that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// parseByteSize parses a number of bytes with an optional K, KB, M, MB, G, GB or B suffix, a blank size is 0
+func parseByteSize(size string) (int64, error) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	if size == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(size, unit.suffix) {
+			size = strings.TrimSpace(strings.TrimSuffix(size, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil || value < 0 {
+		return 0, errors.New("expected a number of bytes such as 500KB, 50MB or 1GB")
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// formatByteSize formats a number of bytes for messages, using the largest unit that keeps it above 1
+func formatByteSize(size int64) string {
+	for i := 2; i >= 0; i-- {
+		unit := byteSizeUnits[i]
+		if size >= unit.multiplier {
+			return strconv.FormatFloat(float64(size)/float64(unit.multiplier), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
+
+// checkFileSize returns a description of the problem if a file is larger than the maximum file size
+func (l uploadLimits) checkFileSize(relativePath string, size int64) (string, bool) {
+	if l.maxFileSize <= 0 || size <= l.maxFileSize {
+		return "", true
+	}
+	return fmt.Sprintf("%v is %v, larger than the maximum file size of %v", relativePath, formatByteSize(size), formatByteSize(l.maxFileSize)), false
+}
+
+// newRateLimiter returns a limiter for the given rate, or nil if the rate is not limited
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{bytesPerSecond: bytesPerSecond}
+}
+
+// reader wraps reader so that it is read no faster than the limit allows, a nil limiter does not limit it
+func (l *rateLimiter) reader(reader io.Reader) io.Reader {
+	if l == nil {
+		return reader
+	}
+	return &rateLimitedReader{reader, l}
+}
+
+// wait records that n bytes were read, sleeping until reading them keeps within the limit
+func (l *rateLimiter) wait(n int) {
+	l.mutex.Lock()
+	if l.start.IsZero() {
+		l.start = time.Now()
+	}
+	l.total += int64(n)
+	due := l.start.Add(time.Duration(float64(l.total) / float64(l.bytesPerSecond) * float64(time.Second)))
+	l.mutex.Unlock()
+
+	time.Sleep(time.Until(due))
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// read in small pieces so the rate stays smooth rather than bursting a whole buffer at once
+	if chunk := int(r.limiter.bytesPerSecond / 10); chunk > 0 && len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
@@ -0,0 +1,175 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		"blank is no limit":       {size: "", want: 0},
+		"plain number of bytes":   {size: "1024", want: 1024},
+		"bytes suffix":            {size: "12B", want: 12},
+		"kilobytes":               {size: "2KB", want: 2048},
+		"short megabytes":         {size: "3m", want: 3 << 20},
+		"fractional gigabytes":    {size: "1.5GB", want: 3 << 29},
+		"space before the suffix": {size: "10 MB", want: 10 << 20},
+		"not a number":            {size: "lots", wantErr: true},
+		"negative":                {size: "-1MB", wantErr: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseByteSize(test.size)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert.Equal(t, "512B", formatByteSize(512))
+	assert.Equal(t, "1.5KB", formatByteSize(1536))
+	assert.Equal(t, "50.0MB", formatByteSize(50<<20))
+	assert.Equal(t, "2.0GB", formatByteSize(2<<30))
+}
+
+func TestResolveUploadLimits(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "limits_test")
+	defer os.RemoveAll(projectPath)
+	cwSettings, _ := json.Marshal(CWSettings{MaxFileSize: "10MB", MaxFileSizePolicy: "skip", UploadRateLimit: "1MB"})
+	ioutil.WriteFile(filepath.Join(projectPath, ".cw-settings"), cwSettings, 0644)
+
+	t.Run("success case - limits are read from .cw-settings", func(t *testing.T) {
+		limits, err := resolveUploadLimits(projectPath, SyncOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, uploadLimits{10 << 20, FileSizePolicySkip, 1 << 20}, limits)
+	})
+
+	t.Run("success case - flags override .cw-settings", func(t *testing.T) {
+		limits, err := resolveUploadLimits(projectPath, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: "FAIL", RateLimit: "2KB"})
+		assert.Nil(t, err)
+		assert.Equal(t, uploadLimits{1 << 10, FileSizePolicyFail, 2 << 10}, limits)
+	})
+
+	t.Run("success case - no limits without settings or flags", func(t *testing.T) {
+		limits, err := resolveUploadLimits(filepath.Join(projectPath, "missing"), SyncOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, uploadLimits{0, FileSizePolicyWarn, 0}, limits)
+	})
+
+	t.Run("fail case - invalid values are reported", func(t *testing.T) {
+		for _, options := range []SyncOptions{{MaxFileSize: "huge"}, {MaxFileSizePolicy: "ignore"}, {RateLimit: "fast"}} {
+			_, err := resolveUploadLimits(projectPath, options)
+			assert.Equal(t, errOpInvalidOptions, err.Op)
+		}
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("reads are slowed to the limit", func(t *testing.T) {
+		limiter := newRateLimiter(10000)
+		start := time.Now()
+		content, err := ioutil.ReadAll(limiter.reader(bytes.NewReader(make([]byte, 2000))))
+		assert.Nil(t, err)
+		assert.Len(t, content, 2000)
+		assert.True(t, time.Since(start) >= 150*time.Millisecond, "2000 bytes at 10000 bytes per second took %v", time.Since(start))
+	})
+
+	t.Run("no limiter is created without a limit", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		assert.Nil(t, limiter)
+		reader := bytes.NewReader([]byte("content"))
+		assert.Equal(t, reader, limiter.reader(reader))
+	})
+}
+
+func TestPlanSyncMaxFileSize(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "limits_test")
+	defer os.RemoveAll(projectPath)
+	ioutil.WriteFile(filepath.Join(projectPath, "small"), make([]byte, 10), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, "large"), make([]byte, 2048), 0644)
+
+	t.Run("success case - large files are uploaded with the warn policy", func(t *testing.T) {
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: FileSizePolicyWarn})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"large", "small"}, plan.modifiedList)
+	})
+
+	t.Run("success case - large files are reported as skipped with the skip policy", func(t *testing.T) {
+		previous := newSyncManifest()
+		previous.Files["large"] = manifestEntry{Size: 1, Hash: "previous"}
+		plan, err := planSync(projectPath, 0, previous, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: FileSizePolicySkip})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"small"}, plan.modifiedList)
+		assert.Equal(t, []string{"large", "small"}, plan.fileList)
+		assert.Len(t, plan.skippedFiles, 1)
+		assert.Equal(t, "large", plan.skippedFiles[0].FilePath)
+		assert.Equal(t, UploadOutcomeSkippedTooLarge, plan.skippedFiles[0].Outcome)
+		assert.Equal(t, "large is 2.0KB, larger than the maximum file size of 1.0KB", plan.skippedFiles[0].Status)
+		// whatever was last synced is kept, so the file is not reported as deleted
+		assert.Equal(t, previous.Files["large"], plan.manifest.Files["large"])
+		assert.Equal(t, []string{}, plan.deletedList)
+	})
+
+	t.Run("success case - unchanged large files are recorded without being read", func(t *testing.T) {
+		info, _ := os.Stat(filepath.Join(projectPath, "large"))
+		synctime := info.ModTime().UnixNano()/1000000 + 1
+		plan, err := planSync(projectPath, synctime, nil, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: FileSizePolicyFail})
+		assert.Nil(t, err)
+		assert.Empty(t, plan.modifiedList)
+		assert.Equal(t, "", plan.manifest.Files["large"].Hash)
+		assert.True(t, plan.manifest.Files["large"].matchesInfo(info))
+	})
+
+	t.Run("success case - large files are never read with the skip or fail policy", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced for root")
+		}
+		largePath := filepath.Join(projectPath, "large")
+		os.Chmod(largePath, 0000)
+		defer os.Chmod(largePath, 0644)
+
+		// a read would report the file as unreadable rather than too large
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: FileSizePolicySkip})
+		assert.Nil(t, err)
+		assert.Len(t, plan.skippedFiles, 1)
+		assert.Equal(t, UploadOutcomeSkippedTooLarge, plan.skippedFiles[0].Outcome)
+
+		plan, err = planSync(projectPath, 0, nil, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: FileSizePolicyFail})
+		assert.Nil(t, plan)
+		assert.Equal(t, errOpSyncLimit, err.Op)
+	})
+
+	t.Run("fail case - nothing is synced with the fail policy", func(t *testing.T) {
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{MaxFileSize: "1KB", MaxFileSizePolicy: FileSizePolicyFail})
+		assert.Nil(t, plan)
+		assert.Equal(t, errOpSyncLimit, err.Op)
+		assert.Contains(t, err.Desc, "large is 2.0KB")
+	})
+}
@@ -0,0 +1,213 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// syncManifestSchemaVersion must be incremented when changing the syncManifest or manifestEntry
+const syncManifestSchemaVersion = 2
+
+type (
+	// syncManifest records the state of every file last synced to PFE for a project
+	syncManifest struct {
+		SchemaVersion int                      `json:"schemaVersion"`
+		Files         map[string]manifestEntry `json:"files"`
+	}
+
+	// manifestEntry is the recorded state of a single synced file
+	manifestEntry struct {
+		Size    int64  `json:"size"`
+		Mode    uint   `json:"mode"`
+		Hash    string `json:"sha256"`
+		ModTime int64  `json:"modTime"` // in nanoseconds, a file with the same size, mode and ModTime is not hashed again
+	}
+)
+
+func newSyncManifest() *syncManifest {
+	return &syncManifest{
+		SchemaVersion: syncManifestSchemaVersion,
+		Files:         make(map[string]manifestEntry),
+	}
+}
+
+// newManifestEntry creates the manifest entry for a file from its info and the sha256 sum of its contents
+func newManifestEntry(info os.FileInfo, sum []byte) manifestEntry {
+	return manifestEntry{
+		Size:    info.Size(),
+		Mode:    uint(info.Mode().Perm()),
+		Hash:    hex.EncodeToString(sum),
+		ModTime: info.ModTime().UnixNano(),
+	}
+}
+
+// hashFile creates the manifest entry for a file, streaming its contents through sha256 rather than reading them into memory
+func hashFile(filePath string, info os.FileInfo) (manifestEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	return newManifestEntry(info, hash.Sum(nil)), nil
+}
+
+// matchesInfo returns true if a file still has the size, mode and modification time it was recorded with,
+// so its contents can be assumed unchanged without reading them
+func (e manifestEntry) matchesInfo(info os.FileInfo) bool {
+	return e.Size == info.Size() && e.Mode == uint(info.Mode().Perm()) && e.ModTime == info.ModTime().UnixNano()
+}
+
+// content returns the entry without its modification time, which does not change what PFE has
+func (e manifestEntry) content() manifestEntry {
+	e.ModTime = 0
+	return e
+}
+
+// hasChanged returns true if the file at relativePath was not recorded in the
+// manifest, or was recorded with a different size, mode or content hash
+func (m *syncManifest) hasChanged(relativePath string, entry manifestEntry) bool {
+	previous, found := m.Files[relativePath]
+	return !found || previous.content() != entry.content()
+}
+
+// deletedSince returns the sorted paths recorded in the previous manifest that are no longer in this one
+func (m *syncManifest) deletedSince(previous *syncManifest) []string {
+	deleted := []string{}
+	if previous == nil {
+		return deleted
+	}
+	for relativePath := range previous.Files {
+		if _, found := m.Files[relativePath]; !found {
+			deleted = append(deleted, relativePath)
+		}
+	}
+	sort.Strings(deleted)
+	return deleted
+}
+
+// findRenames pairs the files that are new since the previous manifest with deleted files that had exactly
+// the same size, mode and content. It returns the renames and the files that were deleted without being renamed.
+func findRenames(previous *syncManifest, modifiedList []string, modifiedEntries map[string]manifestEntry, deleted []string) ([]RenamedFile, []string) {
+	renamed := []RenamedFile{}
+	if previous == nil {
+		return renamed, deleted
+	}
+
+	// deleted is sorted, so when several deleted files match the same one is always chosen
+	deletedByEntry := map[manifestEntry][]string{}
+	for _, relativePath := range deleted {
+		entry := previous.Files[relativePath].content()
+		deletedByEntry[entry] = append(deletedByEntry[entry], relativePath)
+	}
+
+	renamedFrom := map[string]bool{}
+	for _, relativePath := range modifiedList {
+		// a file that was synced before has been changed in place, not moved
+		if _, found := previous.Files[relativePath]; found {
+			continue
+		}
+		entry := modifiedEntries[relativePath]
+		candidates := deletedByEntry[entry.content()]
+		if len(candidates) == 0 {
+			continue
+		}
+		renamed = append(renamed, RenamedFile{From: candidates[0], To: relativePath, entry: entry})
+		renamedFrom[candidates[0]] = true
+		deletedByEntry[entry.content()] = candidates[1:]
+	}
+
+	stillDeleted := []string{}
+	for _, relativePath := range deleted {
+		if !renamedFrom[relativePath] {
+			stillDeleted = append(stillDeleted, relativePath)
+		}
+	}
+	return renamed, stillDeleted
+}
+
+// getSyncManifestFilename : Get full file path of the sync manifest for a project
+func getSyncManifestFilename(projectID string) string {
+	return path.Join(getCodewindHomeDir(), "sync", projectID+".json")
+}
+
+// loadSyncManifest reads the sync manifest for a project, returning nil if the project has never been synced
+func loadSyncManifest(projectID string) (*syncManifest, *ProjectError) {
+	return readSyncManifest(getSyncManifestFilename(projectID))
+}
+
+// saveSyncManifest writes the sync manifest for a project
+func saveSyncManifest(projectID string, manifest *syncManifest) *ProjectError {
+	return writeSyncManifest(getSyncManifestFilename(projectID), manifest)
+}
+
+// RemoveSyncManifest : Remove the sync manifest for a project, so the next sync uploads every file
+func RemoveSyncManifest(projectID string) *ProjectError {
+	err := os.Remove(getSyncManifestFilename(projectID))
+	if err != nil && !os.IsNotExist(err) {
+		return &ProjectError{errOpFileDelete, err, err.Error()}
+	}
+	return nil
+}
+
+func readSyncManifest(filename string) (*syncManifest, *ProjectError) {
+	file, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+
+	var manifest syncManifest
+	err = json.Unmarshal(file, &manifest)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	// an unknown schema cannot be trusted, so treat the project as never synced
+	if manifest.SchemaVersion != syncManifestSchemaVersion || manifest.Files == nil {
+		return nil, nil
+	}
+	return &manifest, nil
+}
+
+func writeSyncManifest(filename string, manifest *syncManifest) *ProjectError {
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return &ProjectError{errOpFileParse, err, err.Error()}
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0777)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+
+	err = ioutil.WriteFile(filename, body, 0644)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
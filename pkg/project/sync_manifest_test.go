@@ -0,0 +1,188 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashFile(t *testing.T) {
+	testDir := "./testDir/TestHashFile"
+	os.MkdirAll(testDir, 0755)
+	defer os.RemoveAll(testDir)
+	filePath := path.Join(testDir, "file")
+	ioutil.WriteFile(filePath, []byte("abcd"), 0644)
+	info, _ := os.Stat(filePath)
+
+	t.Run("success case - the entry has the file's info and the hash of its content", func(t *testing.T) {
+		entry, err := hashFile(filePath, info)
+		assert.Nil(t, err)
+		sum := sha256.Sum256([]byte("abcd"))
+		assert.Equal(t, newManifestEntry(info, sum[:]), entry)
+		assert.Equal(t, int64(4), entry.Size)
+		assert.Equal(t, uint(0644), entry.Mode)
+		assert.True(t, entry.matchesInfo(info))
+	})
+
+	t.Run("fail case - a missing file returns an error", func(t *testing.T) {
+		_, err := hashFile(path.Join(testDir, "missing"), info)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestManifestHasChanged(t *testing.T) {
+	manifest := newSyncManifest()
+	manifest.Files["test"] = manifestEntry{Size: 4, Mode: 0644, Hash: "abcd"}
+
+	tests := map[string]struct {
+		relativePath string
+		entry        manifestEntry
+		want         bool
+	}{
+		"unchanged file": {
+			relativePath: "test",
+			entry:        manifestEntry{Size: 4, Mode: 0644, Hash: "abcd"},
+			want:         false,
+		},
+		"changed content": {
+			relativePath: "test",
+			entry:        manifestEntry{Size: 4, Mode: 0644, Hash: "efgh"},
+			want:         true,
+		},
+		"changed mode": {
+			relativePath: "test",
+			entry:        manifestEntry{Size: 4, Mode: 0755, Hash: "abcd"},
+			want:         true,
+		},
+		"only the modification time changed": {
+			relativePath: "test",
+			entry:        manifestEntry{Size: 4, Mode: 0644, Hash: "abcd", ModTime: 1000},
+			want:         false,
+		},
+		"new file": {
+			relativePath: "new",
+			entry:        manifestEntry{Size: 4, Mode: 0644, Hash: "abcd"},
+			want:         true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, manifest.hasChanged(test.relativePath, test.entry))
+		})
+	}
+}
+
+func TestManifestDeletedSince(t *testing.T) {
+	previous := newSyncManifest()
+	previous.Files["b"] = manifestEntry{}
+	previous.Files["a"] = manifestEntry{}
+	previous.Files["kept"] = manifestEntry{}
+
+	current := newSyncManifest()
+	current.Files["kept"] = manifestEntry{}
+
+	t.Run("files missing from the current manifest are returned in order", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "b"}, current.deletedSince(previous))
+	})
+
+	t.Run("nothing is deleted when there is no previous manifest", func(t *testing.T) {
+		assert.Equal(t, []string{}, current.deletedSince(nil))
+	})
+}
+
+func TestFindRenames(t *testing.T) {
+	entryA := manifestEntry{Size: 1, Mode: 0644, Hash: "aaaa"}
+	entryB := manifestEntry{Size: 1, Mode: 0644, Hash: "bbbb"}
+
+	previous := newSyncManifest()
+	previous.Files["old/a"] = entryA
+	previous.Files["old/b"] = entryB
+	previous.Files["old/c"] = entryA
+	previous.Files["changed"] = entryA
+
+	t.Run("new files with the same content as a deleted file are renames", func(t *testing.T) {
+		modifiedList := []string{"changed", "new/a", "new/b", "new/d"}
+		modifiedEntries := map[string]manifestEntry{
+			"changed": entryB,
+			"new/a":   entryA,
+			"new/b":   entryB,
+			"new/d":   {Size: 1, Mode: 0644, Hash: "dddd"},
+		}
+		renamed, deleted := findRenames(previous, modifiedList, modifiedEntries, []string{"old/a", "old/b", "old/c"})
+		assert.Equal(t, []RenamedFile{
+			{From: "old/a", To: "new/a", entry: entryA},
+			{From: "old/b", To: "new/b", entry: entryB},
+		}, renamed)
+		assert.Equal(t, []string{"old/c"}, deleted)
+	})
+
+	t.Run("a file whose mode changed is not a rename", func(t *testing.T) {
+		modifiedEntries := map[string]manifestEntry{"new/a": {Size: 1, Mode: 0755, Hash: "aaaa"}}
+		renamed, deleted := findRenames(previous, []string{"new/a"}, modifiedEntries, []string{"old/a"})
+		assert.Equal(t, []RenamedFile{}, renamed)
+		assert.Equal(t, []string{"old/a"}, deleted)
+	})
+
+	t.Run("nothing is renamed when there is no previous manifest", func(t *testing.T) {
+		renamed, deleted := findRenames(nil, []string{"new/a"}, map[string]manifestEntry{"new/a": entryA}, []string{})
+		assert.Equal(t, []RenamedFile{}, renamed)
+		assert.Equal(t, []string{}, deleted)
+	})
+}
+
+func TestReadWriteSyncManifest(t *testing.T) {
+	testDir := "sync_manifest_test_folder_delete_me"
+	defer os.RemoveAll(testDir)
+
+	t.Run("success case - manifest written can be read back", func(t *testing.T) {
+		filename := path.Join(testDir, "nested", "manifest.json")
+		manifest := newSyncManifest()
+		manifest.Files["test"] = manifestEntry{Size: 4, Mode: 0644, Hash: "abcd"}
+
+		writeErr := writeSyncManifest(filename, manifest)
+		assert.Nil(t, writeErr)
+
+		got, readErr := readSyncManifest(filename)
+		assert.Nil(t, readErr)
+		assert.Equal(t, manifest, got)
+	})
+
+	t.Run("success case - missing manifest returns nil", func(t *testing.T) {
+		got, err := readSyncManifest(path.Join(testDir, "missing.json"))
+		assert.Nil(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("success case - manifest with an unknown schema version returns nil", func(t *testing.T) {
+		filename := path.Join(testDir, "old.json")
+		ioutil.WriteFile(filename, []byte(`{"schemaVersion":0,"files":{}}`), 0644)
+
+		got, err := readSyncManifest(filename)
+		assert.Nil(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("fail case - corrupt manifest returns an error", func(t *testing.T) {
+		filename := path.Join(testDir, "corrupt.json")
+		ioutil.WriteFile(filename, []byte("not json"), 0644)
+
+		got, err := readSyncManifest(filename)
+		assert.Nil(t, got)
+		assert.Equal(t, errOpFileParse, err.Op)
+	})
+}
@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// refSource is a file or directory on disk that a reference syncs into the project
+type refSource struct {
+	From        string // the absolute path on disk
+	To          string // the slash separated path relative to the project
+	os.FileInfo        // the FileInfo of the path on disk
+}
+
+// expandRefPath resolves a reference into the files and directories it syncs. A From path containing
+// glob characters may match many paths, in which case To is the directory they are synced into.
+func expandRefPath(projectPath string, ref refPath) ([]refSource, error) {
+	from := ref.From
+	if !filepath.IsAbs(from) {
+		from = filepath.Join(projectPath, from)
+	}
+	to := path.Clean(filepath.ToSlash(ref.To))
+	// To must name a path inside the project, or files would be synced outside it
+	if strings.TrimSpace(ref.To) == "" || to == "." {
+		return nil, errors.New("no To path is given")
+	}
+	if path.IsAbs(to) || filepath.IsAbs(ref.To) || to == ".." || strings.HasPrefix(to, "../") {
+		return nil, fmt.Errorf("To path %q is not inside the project", ref.To)
+	}
+
+	if !isGlob(ref.From) {
+		info, err := os.Stat(from)
+		if err != nil {
+			return nil, err
+		}
+		return []refSource{{from, to, info}}, nil
+	}
+
+	matches, err := filepath.Glob(from)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, errors.New("no paths match the pattern")
+	}
+	sources := []refSource{}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, refSource{match, path.Join(to, filepath.Base(match)), info})
+	}
+	return sources, nil
+}
+
+// isGlob returns true if the path contains any of the characters filepath.Match treats as a pattern
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// escapeIgnorePattern escapes a path so an ignore rule created from it only matches that exact path
+func escapeIgnorePattern(path string) string {
+	var escaped strings.Builder
+	for _, c := range path {
+		if strings.ContainsRune(`\*?[!#`, c) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return "/" + escaped.String()
+}
@@ -0,0 +1,197 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createRefPathsTestDirs creates a project that references files in a shared directory beside it
+func createRefPathsTestDirs(t *testing.T, refs []refPath) (string, func()) {
+	testDir, _ := ioutil.TempDir("", "refpaths_test")
+	projectPath := filepath.Join(testDir, "project")
+	sharedPath := filepath.Join(testDir, "shared")
+
+	os.MkdirAll(filepath.Join(sharedPath, "config", "nested"), 0777)
+	os.MkdirAll(filepath.Join(sharedPath, "config", "node_modules"), 0777)
+	os.MkdirAll(filepath.Join(sharedPath, "libs"), 0777)
+	os.MkdirAll(projectPath, 0777)
+
+	ioutil.WriteFile(filepath.Join(sharedPath, "config", "app.yaml"), []byte("app"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "config", "nested", "db.yaml"), []byte("db"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "config", "node_modules", "module.js"), []byte("module"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "libs", "a.jar"), []byte("a"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "libs", "b.jar"), []byte("b"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "libs", "readme.txt"), []byte("readme"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "single.txt"), []byte("single"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, "single.txt"), []byte("shadowed"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, ".cwignore"), []byte("node_modules/\n"), 0644)
+
+	refPathsJSON, _ := json.Marshal(refPaths{RefPaths: refs})
+	err := ioutil.WriteFile(filepath.Join(projectPath, ".cw-refpaths.json"), refPathsJSON, 0644)
+	if err != nil {
+		t.Fatalf("unable to write .cw-refpaths.json: %v", err)
+	}
+	return projectPath, func() { os.RemoveAll(testDir) }
+}
+
+func TestExpandRefPath(t *testing.T) {
+	projectPath, cleanup := createRefPathsTestDirs(t, nil)
+	defer cleanup()
+	sharedPath := filepath.Join(filepath.Dir(projectPath), "shared")
+
+	t.Run("a file maps to its To path", func(t *testing.T) {
+		sources, err := expandRefPath(projectPath, refPath{From: "../shared/single.txt", To: "./single.txt"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(sources))
+		assert.Equal(t, filepath.Join(sharedPath, "single.txt"), sources[0].From)
+		assert.Equal(t, "single.txt", sources[0].To)
+	})
+
+	t.Run("a directory maps to its To path", func(t *testing.T) {
+		sources, err := expandRefPath(projectPath, refPath{From: filepath.Join(sharedPath, "config"), To: "config"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(sources))
+		assert.True(t, sources[0].IsDir())
+	})
+
+	t.Run("a glob maps each match into the To directory", func(t *testing.T) {
+		sources, err := expandRefPath(projectPath, refPath{From: "../shared/libs/*.jar", To: "lib"})
+		assert.Nil(t, err)
+		to := []string{}
+		for _, source := range sources {
+			to = append(to, source.To)
+		}
+		assert.Equal(t, []string{"lib/a.jar", "lib/b.jar"}, to)
+	})
+
+	t.Run("a glob that matches nothing is an error", func(t *testing.T) {
+		_, err := expandRefPath(projectPath, refPath{From: "../shared/libs/*.war", To: "lib"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("a missing path is an error", func(t *testing.T) {
+		_, err := expandRefPath(projectPath, refPath{From: "../shared/missing.txt", To: "missing.txt"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("a To path that is empty or outside the project is an error", func(t *testing.T) {
+		for _, to := range []string{"", "./", "..", "../outside.txt", "config/../../outside.txt", "/etc/passwd"} {
+			_, err := expandRefPath(projectPath, refPath{From: "../shared/single.txt", To: to})
+			assert.NotNil(t, err, to)
+		}
+	})
+}
+
+func TestEscapeIgnorePattern(t *testing.T) {
+	m := ignoreMatcher{}
+	for _, path := range []string{"lib/a.jar", "!important.txt", "#notes", "file[1].txt", "star*.txt"} {
+		rule, ok := newIgnoreRule(escapeIgnorePattern(path), "test", true)
+		assert.True(t, ok, path)
+		m.rules = append(m.rules, *rule)
+	}
+	assert.True(t, m.isIgnored("!important.txt", false))
+	assert.True(t, m.isIgnored("#notes", false))
+	assert.True(t, m.isIgnored("file[1].txt", false))
+	assert.False(t, m.isIgnored("file1.txt", false))
+	assert.False(t, m.isIgnored("starry.txt", false))
+	assert.False(t, m.isIgnored("nested/lib/a.jar", false))
+}
+
+func TestPlanSyncRefPaths(t *testing.T) {
+	t.Run("directories and globs are synced into the project", func(t *testing.T) {
+		projectPath, cleanup := createRefPathsTestDirs(t, []refPath{
+			{From: "../shared/config", To: "config"},
+			{From: "../shared/libs/*.jar", To: "lib"},
+			{From: "../shared/single.txt", To: "single.txt"},
+		})
+		defer cleanup()
+
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"config", "config/nested"}, plan.directoryList)
+		assert.Equal(t, []string{".cw-refpaths.json", ".cwignore", "config/app.yaml", "config/nested/db.yaml", "lib/a.jar", "lib/b.jar", "single.txt"}, plan.fileList)
+		assert.Contains(t, plan.excludedList, ExcludedPath{"config/node_modules", "node_modules/", ".cwignore:1"})
+		assert.Contains(t, plan.excludedList, ExcludedPath{"single.txt", "/single.txt", ignoreSourceRefPaths})
+
+		for _, upload := range plan.uploads {
+			if upload.RelativePath == "single.txt" {
+				content, _ := ioutil.ReadFile(upload.Path)
+				assert.Equal(t, "single", string(content))
+			}
+		}
+	})
+
+	t.Run("references that map to the same path are reported", func(t *testing.T) {
+		projectPath, cleanup := createRefPathsTestDirs(t, []refPath{
+			{From: "../shared/single.txt", To: "lib/a.jar"},
+			{From: "../shared/libs/*.jar", To: "lib"},
+		})
+		defer cleanup()
+
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{})
+		assert.Equal(t, errOpSyncRef, err.Op)
+		assert.Contains(t, err.Desc, "conflicting file references")
+		assert.Equal(t, []string{".cw-refpaths.json", ".cwignore", "single.txt", "lib/a.jar", "lib/b.jar"}, plan.fileList)
+	})
+
+	t.Run("a reference that escapes the project is reported and not synced", func(t *testing.T) {
+		projectPath, cleanup := createRefPathsTestDirs(t, []refPath{
+			{From: "../shared/config", To: "../escaped"},
+			{From: "../shared/single.txt", To: "single.txt"},
+		})
+		defer cleanup()
+
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{})
+		assert.Equal(t, errOpSyncRef, err.Op)
+		assert.Contains(t, err.Desc, "is not inside the project")
+		assert.Equal(t, []string{".cw-refpaths.json", ".cwignore", "single.txt"}, plan.fileList)
+		assert.Empty(t, plan.directoryList)
+	})
+
+	t.Run("unreadable paths in a referenced directory are reported and skipped", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced for root")
+		}
+		projectPath, cleanup := createRefPathsTestDirs(t, []refPath{{From: "../shared/config", To: "config"}})
+		defer cleanup()
+		nestedPath := filepath.Join(filepath.Dir(projectPath), "shared", "config", "nested")
+		os.Chmod(nestedPath, 0000)
+		defer os.Chmod(nestedPath, 0777)
+
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{".cw-refpaths.json", ".cwignore", "single.txt", "config/app.yaml"}, plan.fileList)
+		assert.Len(t, plan.skippedFiles, 1)
+		assert.Equal(t, "config/nested", plan.skippedFiles[0].FilePath)
+		assert.Equal(t, UploadOutcomeSkippedUnreadable, plan.skippedFiles[0].Outcome)
+	})
+
+	t.Run("invalid references are reported and the rest are synced", func(t *testing.T) {
+		projectPath, cleanup := createRefPathsTestDirs(t, []refPath{
+			{From: "../shared/missing.txt", To: "missing.txt"},
+			{From: "../shared/single.txt", To: "single.txt"},
+		})
+		defer cleanup()
+
+		plan, err := planSync(projectPath, 0, nil, SyncOptions{})
+		assert.Equal(t, errOpSyncRef, err.Op)
+		assert.Contains(t, err.Desc, "invalid file reference")
+		assert.Equal(t, []string{".cw-refpaths.json", ".cwignore", "single.txt"}, plan.fileList)
+	})
+}
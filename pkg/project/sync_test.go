@@ -17,11 +17,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"testing"
 	"time"
 
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	"github.com/eclipse/codewind-installer/pkg/connections"
 	"github.com/eclipse/codewind-installer/pkg/security"
 	"github.com/stretchr/testify/assert"
@@ -151,7 +153,9 @@ func TestIgnoreFileOrDirectory(t *testing.T) {
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			fileIsIgnored := ignoreFileOrDirectory(test.name, test.isDir, test.ignoredPathsList)
+			matcher := newIgnoreMatcher()
+			matcher.addCwSettingsPatterns(test.ignoredPathsList)
+			fileIsIgnored := matcher.isIgnored(test.name, test.isDir)
 
 			assert.IsType(t, test.shouldBeIgnored, fileIsIgnored, "Got: %s", fileIsIgnored)
 
@@ -192,7 +196,7 @@ func TestSyncFiles(t *testing.T) {
 		ioutil.WriteFile(path.Join(mockProjectPath, "test"), []byte{}, 0644)
 		ioutil.WriteFile(path.Join(mockProjectPath, ".cw-settings"), cwSettingsFile, 0644)
 
-		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, &mockConnection)
+		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
 		if err != nil {
 			t.Errorf("syncFiles() failed with error: %s", err)
 		}
@@ -210,7 +214,7 @@ func TestSyncFiles(t *testing.T) {
 		ioutil.WriteFile(path.Join(mockProjectPath, "testfile"), []byte{}, 0644)
 		ioutil.WriteFile(path.Join(mockProjectPath, ".cw-settings"), cwSettingsFile, 0644)
 
-		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, &mockConnection)
+		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
 		if err != nil {
 			t.Errorf("syncFiles() failed with error: %s", err)
 		}
@@ -229,7 +233,7 @@ func TestSyncFiles(t *testing.T) {
 		ioutil.WriteFile(path.Join(newDirPath, "test"), []byte{}, 0644)
 		ioutil.WriteFile(path.Join(mockProjectPath, ".cw-settings"), cwSettingsFile, 0644)
 
-		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, &mockConnection)
+		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
 		if err != nil {
 			t.Errorf("syncFiles() failed with error: %s", err)
 		}
@@ -262,7 +266,7 @@ func TestSyncFiles(t *testing.T) {
 		time.Sleep(1 * time.Second)
 		ioutil.WriteFile(modTestPath, newContent, 0644)
 
-		got, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", modifiedTime, &mockConnection)
+		got, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", modifiedTime, nil, SyncOptions{}, &mockConnection)
 
 		expectedFileList := []string{".cw-settings", "nested-dir/testmod", "nested-dir/testnomod"}
 		expectedDirList := []string{"nested-dir"}
@@ -272,8 +276,236 @@ func TestSyncFiles(t *testing.T) {
 		assert.Equal(t, got.modifiedList, expectedModList)
 	})
 
+	t.Run("success case - touched file with unchanged content is not added to modified list", func(t *testing.T) {
+		mockProjectPath := path.Join(testDir, "manifest-touched")
+		os.Mkdir(mockProjectPath, 0777)
+
+		testPath := path.Join(mockProjectPath, "test")
+		ioutil.WriteFile(path.Join(mockProjectPath, ".cw-settings"), cwSettingsFile, 0644)
+		ioutil.WriteFile(testPath, []byte("unchanged"), 0644)
+
+		first, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
+		assert.Equal(t, []string{".cw-settings", "test"}, first.modifiedList)
+
+		// move the modification time forward without changing the content
+		future := time.Now().Add(1 * time.Hour)
+		os.Chtimes(testPath, future, future)
+
+		got, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, first.manifest, SyncOptions{}, &mockConnection)
+		assert.Empty(t, got.modifiedList)
+		assert.Empty(t, got.deletedList)
+		assert.Equal(t, first.manifest.Files["test"].Hash, got.manifest.Files["test"].Hash)
+		// the new modification time is recorded, so the file is not hashed again next time
+		assert.Equal(t, future.UnixNano(), got.manifest.Files["test"].ModTime)
+	})
+
+	t.Run("success case - file with the size, mode and modification time it was synced with is not read again", func(t *testing.T) {
+		mockProjectPath := path.Join(testDir, "manifest-unread")
+		os.Mkdir(mockProjectPath, 0777)
+
+		testPath := path.Join(mockProjectPath, "test")
+		ioutil.WriteFile(testPath, []byte("content"), 0644)
+		info, _ := os.Stat(testPath)
+
+		// the recorded hash is not the hash of the content, so it is only kept if the file was not hashed
+		previous := newSyncManifest()
+		previous.Files["test"] = newManifestEntry(info, []byte("recorded"))
+
+		got, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, previous, SyncOptions{}, &mockConnection)
+		assert.Empty(t, got.modifiedList)
+		assert.Equal(t, previous.Files["test"], got.manifest.Files["test"])
+	})
+
+	t.Run("success case - file with changed content is added to modified list", func(t *testing.T) {
+		mockProjectPath := path.Join(testDir, "manifest-changed")
+		os.Mkdir(mockProjectPath, 0777)
+
+		testPath := path.Join(mockProjectPath, "test")
+		ioutil.WriteFile(path.Join(mockProjectPath, ".cw-settings"), cwSettingsFile, 0644)
+		ioutil.WriteFile(testPath, []byte("before"), 0644)
+		info, _ := os.Stat(testPath)
+
+		first, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
+
+		// same size, so only the content hash can tell them apart
+		ioutil.WriteFile(testPath, []byte("after!"), 0644)
+		later := info.ModTime().Add(1 * time.Hour)
+		os.Chtimes(testPath, later, later)
+
+		got, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, first.manifest, SyncOptions{}, &mockConnection)
+		assert.Equal(t, []string{"test"}, got.modifiedList)
+		assert.NotEqual(t, first.manifest.Files["test"].Hash, got.manifest.Files["test"].Hash)
+	})
+
+	t.Run("success case - file removed since last sync is added to deleted list", func(t *testing.T) {
+		mockProjectPath := path.Join(testDir, "manifest-deleted")
+		os.Mkdir(mockProjectPath, 0777)
+
+		testPath := path.Join(mockProjectPath, "test")
+		ioutil.WriteFile(path.Join(mockProjectPath, ".cw-settings"), cwSettingsFile, 0644)
+		ioutil.WriteFile(testPath, []byte{}, 0644)
+
+		first, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
+		os.Remove(testPath)
+
+		got, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, first.manifest, SyncOptions{}, &mockConnection)
+		assert.Equal(t, []string{".cw-settings"}, got.fileList)
+		assert.Empty(t, got.modifiedList)
+		assert.Equal(t, []string{"test"}, got.deletedList)
+	})
+
+	t.Run("fail case - file rejected by PFE is not recorded in the manifest", func(t *testing.T) {
+		mockProjectPath := path.Join(testDir, "manifest-rejected")
+		os.Mkdir(mockProjectPath, 0777)
+
+		ioutil.WriteFile(path.Join(mockProjectPath, "test"), []byte{}, 0644)
+
+		rejectBody := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		rejectClient := &security.ClientMockAuthenticate{StatusCode: http.StatusInternalServerError, Body: rejectBody}
+		got, _ := syncFiles(rejectClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
+		assert.Equal(t, []string{"test"}, got.modifiedList)
+		assert.Empty(t, got.manifest.Files)
+	})
+
+	t.Run("success case - unreadable directory is reported and skipped, and its files are not deleted", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced for root")
+		}
+		mockProjectPath := path.Join(testDir, "unreadable-dir")
+		lockedPath := path.Join(mockProjectPath, "locked")
+		os.MkdirAll(lockedPath, 0777)
+		ioutil.WriteFile(path.Join(mockProjectPath, "test"), []byte{}, 0644)
+		ioutil.WriteFile(path.Join(lockedPath, "secret"), []byte("secret"), 0644)
+
+		first, _ := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, nil, SyncOptions{}, &mockConnection)
+		os.Chmod(lockedPath, 0000)
+		defer os.Chmod(lockedPath, 0777)
+
+		got, err := syncFiles(mockClient, mockProjectPath, "mockID", "dummyURL", 0, first.manifest, SyncOptions{}, &mockConnection)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"test"}, got.fileList)
+		assert.Empty(t, got.deletedList)
+		assert.Contains(t, got.manifest.Files, "locked/secret")
+		skipped := []string{}
+		for _, file := range got.UploadedFileList {
+			if file.Outcome == UploadOutcomeSkippedUnreadable {
+				skipped = append(skipped, file.FilePath)
+			}
+		}
+		assert.Equal(t, []string{"locked"}, skipped)
+	})
+
+	cleanupTestFolder(t, testDir)
+}
+
+func TestSyncFilesUploadMode(t *testing.T) {
+	testDir := "sync_test_folder_delete_me"
+	mockProjectPath := path.Join(testDir, "upload-mode")
+	os.MkdirAll(mockProjectPath, 0777)
+	ioutil.WriteFile(path.Join(mockProjectPath, "a"), []byte("a"), 0644)
+	ioutil.WriteFile(path.Join(mockProjectPath, "b"), []byte("b"), 0644)
+	mockConnection := connections.Connection{ID: "local"}
+
+	// newMockPFE starts a stand-in for PFE that counts the uploads it receives
+	newMockPFE := func(capabilities []string, fileUploads *int, batchUploads *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/environment":
+				json.NewEncoder(w).Encode(apiroutes.EnvResponse{Version: "x.x.dev", Capabilities: capabilities})
+			case "/api/v1/projects/mockID/upload":
+				*fileUploads++
+			case "/api/v1/projects/mockID/upload/batch":
+				*batchUploads++
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+
+	t.Run("success case - files are uploaded in one archive when PFE supports it", func(t *testing.T) {
+		fileUploads, batchUploads := 0, 0
+		server := newMockPFE([]string{apiroutes.CapabilityBatchUpload}, &fileUploads, &batchUploads)
+		defer server.Close()
+
+		got, err := syncFiles(http.DefaultClient, mockProjectPath, "mockID", server.URL, 0, nil, SyncOptions{}, &mockConnection)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, fileUploads)
+		assert.Equal(t, 1, batchUploads)
+		assert.Len(t, got.UploadedFileList, 2)
+		assert.Len(t, got.manifest.Files, 2)
+	})
+
+	t.Run("success case - files are uploaded one at a time when PFE does not support batches", func(t *testing.T) {
+		fileUploads, batchUploads := 0, 0
+		server := newMockPFE([]string{}, &fileUploads, &batchUploads)
+		defer server.Close()
+
+		got, err := syncFiles(http.DefaultClient, mockProjectPath, "mockID", server.URL, 0, nil, SyncOptions{Concurrency: 1}, &mockConnection)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, fileUploads)
+		assert.Equal(t, 0, batchUploads)
+		assert.Len(t, got.manifest.Files, 2)
+	})
+
+	t.Run("success case - files are uploaded one at a time when the batch endpoint is missing", func(t *testing.T) {
+		fileUploads := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/environment":
+				json.NewEncoder(w).Encode(apiroutes.EnvResponse{Capabilities: []string{apiroutes.CapabilityBatchUpload}})
+			case "/api/v1/projects/mockID/upload":
+				fileUploads++
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		got, err := syncFiles(http.DefaultClient, mockProjectPath, "mockID", server.URL, 0, nil, SyncOptions{Concurrency: 1}, &mockConnection)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, fileUploads)
+		assert.Len(t, got.manifest.Files, 2)
+	})
+
+	// the previous sync saw "a" at another path, and "b" where it is now
+	infoA, _ := os.Stat(path.Join(mockProjectPath, "a"))
+	infoB, _ := os.Stat(path.Join(mockProjectPath, "b"))
+	previousManifest := newSyncManifest()
+	previousManifest.Files["old-a"], _ = hashFile(path.Join(mockProjectPath, "a"), infoA)
+	previousManifest.Files["b"], _ = hashFile(path.Join(mockProjectPath, "b"), infoB)
+	previousManifest.Files["deleted"] = manifestEntry{Size: 7, Mode: 0644, Hash: "deleted"}
+
+	t.Run("success case - renamed files are moved by PFE when it supports it", func(t *testing.T) {
+		fileUploads, batchUploads := 0, 0
+		server := newMockPFE([]string{apiroutes.CapabilityRenameFiles}, &fileUploads, &batchUploads)
+		defer server.Close()
+
+		got, err := syncFiles(http.DefaultClient, mockProjectPath, "mockID", server.URL, 0, previousManifest, SyncOptions{}, &mockConnection)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, fileUploads)
+		assert.Equal(t, []RenamedFile{{From: "old-a", To: "a", entry: previousManifest.Files["old-a"]}}, got.renamedList)
+		assert.Equal(t, []string{"deleted"}, got.deletedList)
+		assert.Equal(t, []string{}, got.modifiedList)
+		assert.Len(t, got.manifest.Files, 2)
+	})
+
+	t.Run("success case - renamed files are deleted and uploaded when PFE cannot move them", func(t *testing.T) {
+		fileUploads, batchUploads := 0, 0
+		server := newMockPFE([]string{}, &fileUploads, &batchUploads)
+		defer server.Close()
+
+		got, err := syncFiles(http.DefaultClient, mockProjectPath, "mockID", server.URL, 0, previousManifest, SyncOptions{}, &mockConnection)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, fileUploads)
+		assert.Equal(t, []RenamedFile{}, got.renamedList)
+		assert.Equal(t, []string{"deleted", "old-a"}, got.deletedList)
+		assert.Equal(t, []string{"a"}, got.modifiedList)
+		assert.Len(t, got.manifest.Files, 2)
+	})
+
 	cleanupTestFolder(t, testDir)
 }
+
 func TestRetrieveIgnoredPathsList(t *testing.T) {
 	testFolder := "sync_test_folder_delete_me"
 	createTestDirPaths := createTestPathsForIgnoredPathsTests(t, testFolder)
@@ -0,0 +1,312 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// DefaultSyncConcurrency is the number of files uploaded in parallel when no concurrency is given
+const DefaultSyncConcurrency = 4
+
+// maxUploadAttempts is the number of times a file is sent before it is reported as failed
+const maxUploadAttempts = 3
+
+// uploadRetryBackoff is the wait before the first retry of an upload
+var uploadRetryBackoff = 500 * time.Millisecond
+
+// Upload outcomes reported for each file in a sync
+const (
+	UploadOutcomeUploaded          = "uploaded"           // uploaded on the first attempt
+	UploadOutcomeRetried           = "retried"            // uploaded after retrying
+	UploadOutcomeSkippedUnreadable = "skipped-unreadable" // not uploaded as the file could not be read
+	UploadOutcomeSkippedTooLarge   = "skipped-too-large"  // not uploaded as the file is larger than the maximum file size
+	UploadOutcomeFailed            = "failed"             // rejected by PFE, or PFE could not be reached
+)
+
+type (
+	// SyncOptions controls how the files of a project are uploaded
+	SyncOptions struct {
+		Concurrency       int    // the number of files to upload in parallel
+		UseGitignore      bool   // also ignore the paths in the project's .gitignore
+		UseDockerignore   bool   // also ignore the paths in the project's .dockerignore
+		MaxFileSize       string // overrides the maxFileSize in .cw-settings
+		MaxFileSizePolicy string // overrides the maxFileSizePolicy in .cw-settings
+		RateLimit         string // overrides the uploadRateLimit in .cw-settings
+	}
+
+	// fileUpload is a modified file waiting to be uploaded
+	fileUpload struct {
+		RelativePath string // the path PFE will store the file at
+		Path         string // the path of the file on disk
+		os.FileInfo         // the FileInfo of the file on disk
+	}
+
+	// uploadResult is the outcome of uploading a single file
+	uploadResult struct {
+		UploadedFile
+		entry    manifestEntry // the state of the file that was uploaded
+		accepted bool          // true if PFE accepted the file
+	}
+)
+
+// syncOptionsFromContext reads the sync options from the command line flags
+func syncOptionsFromContext(c *cli.Context) SyncOptions {
+	return SyncOptions{
+		Concurrency:       c.Int("concurrency"),
+		UseGitignore:      c.Bool("gitignore"),
+		UseDockerignore:   c.Bool("dockerignore"),
+		MaxFileSize:       c.String("max-file-size"),
+		MaxFileSizePolicy: c.String("max-file-size-policy"),
+		RateLimit:         c.String("rate-limit"),
+	}
+}
+
+// uploadFiles uploads every file to PFE, using up to options.Concurrency parallel requests.
+// The results are returned in the same order as the uploads.
+func uploadFiles(client utils.HTTPClient, projectUploadURL string, uploads []fileUpload, options SyncOptions, limiter *rateLimiter, connection *connections.Connection) []uploadResult {
+	results := make([]uploadResult, len(uploads))
+	utils.RunInParallel(len(uploads), options.Concurrency, func(index int) {
+		results[index] = uploadFile(client, projectUploadURL, uploads[index], limiter, connection)
+	})
+	return results
+}
+
+// uploadFile compresses a single file and PUTs it to PFE
+func uploadFile(client utils.HTTPClient, projectUploadURL string, upload fileUpload, limiter *rateLimiter, connection *connections.Connection) uploadResult {
+	result := uploadResult{UploadedFile: UploadedFile{FilePath: upload.RelativePath}}
+
+	fileContent, err := ioutil.ReadFile(upload.Path)
+	if err != nil {
+		result.Status = err.Error()
+		result.Outcome = UploadOutcomeSkippedUnreadable
+		return result
+	}
+	sum := sha256.Sum256(fileContent)
+	result.entry = newManifestEntry(upload.FileInfo, sum[:])
+
+	var buffer bytes.Buffer
+	zWriter := zlib.NewWriter(&buffer)
+	zWriter.Write(fileContent)
+	zWriter.Close()
+
+	fileUploadBody := FileUploadMsg{
+		IsDirectory:  false,
+		Mode:         result.entry.Mode,
+		RelativePath: upload.RelativePath,
+		Message:      base64.StdEncoding.EncodeToString(buffer.Bytes()),
+	}
+
+	buf := new(bytes.Buffer)
+	json.NewEncoder(buf).Encode(fileUploadBody)
+
+	attempts := withRetry(func() bool {
+		request, err := http.NewRequest("PUT", projectUploadURL, limiter.reader(bytes.NewReader(buf.Bytes())))
+		if err != nil {
+			result.Status = err.Error()
+			return false
+		}
+		request.ContentLength = int64(buf.Len())
+		request.Header.Set("Content-Type", "application/json")
+		resp, httpSecError := sechttp.DispatchHTTPRequest(client, request, connection)
+		if httpSecError != nil {
+			result.Status = httpSecError.Desc
+			result.StatusCode = 0
+			return shouldRetry(0, httpSecError)
+		}
+		resp.Body.Close()
+
+		result.Status = resp.Status
+		result.StatusCode = resp.StatusCode
+		result.accepted = isSuccessStatus(resp.StatusCode)
+		return shouldRetry(resp.StatusCode, nil)
+	})
+	result.setOutcome(attempts)
+	return result
+}
+
+// uploadBatch streams a single tar.gz of every file to PFE's batch upload endpoint.
+// It returns false if PFE does not provide the endpoint, so the caller can fall back to uploadFiles.
+func uploadBatch(client utils.HTTPClient, batchUploadURL string, uploads []fileUpload, limiter *rateLimiter, connection *connections.Connection) ([]uploadResult, bool) {
+	results := make([]uploadResult, len(uploads))
+	archived := make([]bool, len(uploads))
+	supported := true
+	status := ""
+	statusCode := 0
+
+	attempts := withRetry(func() bool {
+		for i, upload := range uploads {
+			results[i] = uploadResult{UploadedFile: UploadedFile{FilePath: upload.RelativePath}}
+			archived[i] = false
+		}
+
+		// write the archive on a separate goroutine so it is streamed rather than held in memory
+		reader, writer := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			writer.CloseWithError(writeBatchArchive(writer, uploads, results, archived))
+		}()
+
+		request, err := http.NewRequest("PUT", batchUploadURL, limiter.reader(reader))
+		if err != nil {
+			reader.Close()
+			<-done
+			supported = false
+			return false
+		}
+		request.Header.Set("Content-Type", "application/gzip")
+		resp, httpSecError := sechttp.DispatchHTTPRequest(client, request, connection)
+
+		// stop the archive writer if PFE responded without reading the whole archive
+		reader.Close()
+		<-done
+
+		if httpSecError != nil {
+			status = httpSecError.Desc
+			statusCode = 0
+			return shouldRetry(0, httpSecError)
+		}
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			supported = false
+			return false
+		}
+		status = resp.Status
+		statusCode = resp.StatusCode
+		return shouldRetry(resp.StatusCode, nil)
+	})
+	if !supported {
+		return nil, false
+	}
+
+	// unreadable files were given their outcome when the archive was written
+	for i := range results {
+		if archived[i] {
+			results[i].Status = status
+			results[i].StatusCode = statusCode
+			results[i].accepted = isSuccessStatus(statusCode)
+			results[i].setOutcome(attempts)
+		}
+	}
+	return results, true
+}
+
+// writeBatchArchive writes every readable file to w as a tar.gz, recording the state of each
+// file written in results and marking it as archived
+func writeBatchArchive(w io.Writer, uploads []fileUpload, results []uploadResult, archived []bool) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for i, upload := range uploads {
+		fileContent, err := ioutil.ReadFile(upload.Path)
+		if err != nil {
+			results[i].Status = err.Error()
+			results[i].Outcome = UploadOutcomeSkippedUnreadable
+			continue
+		}
+		sum := sha256.Sum256(fileContent)
+		entry := newManifestEntry(upload.FileInfo, sum[:])
+
+		header := &tar.Header{
+			Name:    upload.RelativePath,
+			Mode:    int64(entry.Mode),
+			Size:    int64(len(fileContent)),
+			ModTime: upload.ModTime(),
+		}
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = tarWriter.Write(fileContent)
+		if err != nil {
+			return err
+		}
+		results[i].entry = entry
+		archived[i] = true
+	}
+
+	err := tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// withRetry calls send until it reports there is no point retrying, or maxUploadAttempts is reached,
+// doubling the wait before each retry. It returns the number of attempts made.
+func withRetry(send func() (retry bool)) int {
+	attempts := 0
+	backoff := uploadRetryBackoff
+	for {
+		attempts++
+		if !send() || attempts >= maxUploadAttempts {
+			return attempts
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// shouldRetry returns true if PFE could not be reached or returned a server error
+func shouldRetry(statusCode int, httpSecError *sechttp.HTTPSecError) bool {
+	if httpSecError != nil {
+		return httpSecError.IsConnectionError()
+	}
+	return statusCode >= 500
+}
+
+func isSuccessStatus(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+// setOutcome records how the upload of a file that was read ended, after the given number of attempts
+func (r *uploadResult) setOutcome(attempts int) {
+	r.Attempts = attempts
+	switch {
+	case !r.accepted:
+		r.Outcome = UploadOutcomeFailed
+	case attempts > 1:
+		r.Outcome = UploadOutcomeRetried
+	default:
+		r.Outcome = UploadOutcomeUploaded
+	}
+}
+
+// FailedUploads : Get the files that were not uploaded after every attempt
+func FailedUploads(uploadedFiles []UploadedFile) []UploadedFile {
+	failed := []UploadedFile{}
+	for _, file := range uploadedFiles {
+		if file.Outcome == UploadOutcomeFailed {
+			failed = append(failed, file)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,298 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	// keep tests that retry uploads fast
+	uploadRetryBackoff = time.Millisecond
+}
+
+// clientMockInFlight records the largest number of requests it handled at the same time
+type clientMockInFlight struct {
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *clientMockInFlight) Do(req *http.Request) (*http.Response, error) {
+	c.mutex.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mutex.Lock()
+	c.inFlight--
+	c.mutex.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+	}, nil
+}
+
+func TestUploadFiles(t *testing.T) {
+	testDir := "sync_upload_test_folder_delete_me"
+	os.Mkdir(testDir, 0777)
+	defer os.RemoveAll(testDir)
+	mockConnection := connections.Connection{ID: "local"}
+
+	var uploads []fileUpload
+	for i := 0; i < 10; i++ {
+		relativePath := "file" + strconv.Itoa(i)
+		filePath := path.Join(testDir, relativePath)
+		ioutil.WriteFile(filePath, []byte(relativePath), 0644)
+		info, _ := os.Stat(filePath)
+		uploads = append(uploads, fileUpload{relativePath, filePath, info})
+	}
+
+	t.Run("success case - results are in upload order and concurrency is bounded", func(t *testing.T) {
+		mockClient := &clientMockInFlight{}
+		results := uploadFiles(mockClient, "dummyURL", uploads, SyncOptions{Concurrency: 3}, nil, &mockConnection)
+
+		assert.Equal(t, len(uploads), len(results))
+		for i, result := range results {
+			assert.Equal(t, uploads[i].RelativePath, result.FilePath)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.Equal(t, UploadOutcomeUploaded, result.Outcome)
+			assert.Equal(t, 1, result.Attempts)
+			assert.True(t, result.accepted)
+		}
+		assert.True(t, mockClient.maxInFlight <= 3, "maxInFlight was %v", mockClient.maxInFlight)
+		assert.True(t, mockClient.maxInFlight > 1, "uploads were not run in parallel")
+	})
+
+	t.Run("success case - a concurrency below 1 uploads files one at a time", func(t *testing.T) {
+		mockClient := &clientMockInFlight{}
+		results := uploadFiles(mockClient, "dummyURL", uploads, SyncOptions{Concurrency: 0}, nil, &mockConnection)
+
+		assert.Equal(t, len(uploads), len(results))
+		assert.Equal(t, 1, mockClient.maxInFlight)
+	})
+
+	t.Run("fail case - rejected upload is not accepted", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &security.ClientMockAuthenticate{StatusCode: http.StatusInternalServerError, Body: body}
+		results := uploadFiles(mockClient, "dummyURL", uploads[:1], SyncOptions{Concurrency: 2}, nil, &mockConnection)
+
+		assert.Equal(t, http.StatusInternalServerError, results[0].StatusCode)
+		assert.Equal(t, UploadOutcomeFailed, results[0].Outcome)
+		assert.Equal(t, maxUploadAttempts, results[0].Attempts)
+		assert.False(t, results[0].accepted)
+	})
+
+	t.Run("fail case - upload rejected as a bad request is not retried", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &security.ClientMockAuthenticate{StatusCode: http.StatusBadRequest, Body: body}
+		results := uploadFiles(mockClient, "dummyURL", uploads[:1], SyncOptions{Concurrency: 2}, nil, &mockConnection)
+
+		assert.Equal(t, UploadOutcomeFailed, results[0].Outcome)
+		assert.Equal(t, 1, results[0].Attempts)
+	})
+
+	t.Run("success case - upload is retried after a server error", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests < maxUploadAttempts {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		results := uploadFiles(http.DefaultClient, server.URL, uploads[:1], SyncOptions{Concurrency: 1}, nil, &mockConnection)
+		assert.Equal(t, http.StatusOK, results[0].StatusCode)
+		assert.Equal(t, UploadOutcomeRetried, results[0].Outcome)
+		assert.Equal(t, maxUploadAttempts, results[0].Attempts)
+		assert.True(t, results[0].accepted)
+	})
+
+	t.Run("fail case - unreadable file is skipped", func(t *testing.T) {
+		missing := []fileUpload{{"missing", path.Join(testDir, "missing"), uploads[0].FileInfo}}
+		results := uploadFiles(&clientMockInFlight{}, "dummyURL", missing, SyncOptions{Concurrency: 1}, nil, &mockConnection)
+
+		assert.Equal(t, UploadOutcomeSkippedUnreadable, results[0].Outcome)
+		assert.Equal(t, 0, results[0].Attempts)
+		assert.False(t, results[0].accepted)
+	})
+
+	t.Run("fail case - failed request is reported without a status code", func(t *testing.T) {
+		mockClient := &security.ClientMockRequestFail{}
+		results := uploadFiles(mockClient, "dummyURL", uploads[:1], SyncOptions{Concurrency: 2}, nil, &mockConnection)
+
+		assert.Equal(t, 0, results[0].StatusCode)
+		assert.NotEmpty(t, results[0].Status)
+		assert.Equal(t, UploadOutcomeFailed, results[0].Outcome)
+		assert.Equal(t, maxUploadAttempts, results[0].Attempts)
+		assert.False(t, results[0].accepted)
+	})
+}
+
+// readBatchArchive returns the contents of every file in a tar.gz batch upload
+func readBatchArchive(t *testing.T, body io.Reader) map[string]string {
+	files := make(map[string]string)
+	gzipReader, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatalf("batch upload was not gzipped: %v", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("batch upload was not a tar: %v", err)
+		}
+		content, _ := ioutil.ReadAll(tarReader)
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func TestUploadBatch(t *testing.T) {
+	testDir := "sync_batch_test_folder_delete_me"
+	os.MkdirAll(path.Join(testDir, "nested"), 0777)
+	defer os.RemoveAll(testDir)
+	mockConnection := connections.Connection{ID: "local"}
+
+	var uploads []fileUpload
+	for _, relativePath := range []string{"file", "nested/file"} {
+		filePath := path.Join(testDir, relativePath)
+		ioutil.WriteFile(filePath, []byte("content of "+relativePath), 0644)
+		info, _ := os.Stat(filePath)
+		uploads = append(uploads, fileUpload{relativePath, filePath, info})
+	}
+
+	t.Run("success case - every file is sent in a single archive", func(t *testing.T) {
+		var received map[string]string
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			assert.Equal(t, "PUT", r.Method)
+			assert.Equal(t, "application/gzip", r.Header.Get("Content-Type"))
+			received = readBatchArchive(t, r.Body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		results, supported := uploadBatch(http.DefaultClient, server.URL+"/upload/batch", uploads, nil, &mockConnection)
+		assert.True(t, supported)
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, map[string]string{"file": "content of file", "nested/file": "content of nested/file"}, received)
+		for i, result := range results {
+			assert.Equal(t, uploads[i].RelativePath, result.FilePath)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.True(t, result.accepted)
+			assert.NotEmpty(t, result.entry.Hash)
+		}
+	})
+
+	t.Run("success case - unreadable file is left out of the archive", func(t *testing.T) {
+		missing := append([]fileUpload{}, uploads...)
+		missing = append(missing, fileUpload{"missing", path.Join(testDir, "missing"), uploads[0].FileInfo})
+
+		var received map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = readBatchArchive(t, r.Body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		results, supported := uploadBatch(http.DefaultClient, server.URL+"/upload/batch", missing, nil, &mockConnection)
+		assert.True(t, supported)
+		assert.Len(t, received, 2)
+		assert.False(t, results[2].accepted)
+		assert.Equal(t, 0, results[2].StatusCode)
+		assert.Equal(t, UploadOutcomeSkippedUnreadable, results[2].Outcome)
+	})
+
+	t.Run("success case - archive is sent again after a server error", func(t *testing.T) {
+		requests := 0
+		var received map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			received = readBatchArchive(t, r.Body)
+			if requests == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		defer server.Close()
+
+		results, supported := uploadBatch(http.DefaultClient, server.URL+"/upload/batch", uploads, nil, &mockConnection)
+		assert.True(t, supported)
+		assert.Equal(t, 2, requests)
+		assert.Len(t, received, 2)
+		for _, result := range results {
+			assert.Equal(t, UploadOutcomeRetried, result.Outcome)
+			assert.Equal(t, 2, result.Attempts)
+			assert.True(t, result.accepted)
+		}
+	})
+
+	t.Run("fail case - rejected archive marks every file as not accepted", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		results, supported := uploadBatch(http.DefaultClient, server.URL+"/upload/batch", uploads, nil, &mockConnection)
+		assert.True(t, supported)
+		for _, result := range results {
+			assert.Equal(t, http.StatusInternalServerError, result.StatusCode)
+			assert.Equal(t, UploadOutcomeFailed, result.Outcome)
+			assert.False(t, result.accepted)
+		}
+	})
+
+	t.Run("fail case - missing endpoint is reported as unsupported", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		results, supported := uploadBatch(http.DefaultClient, server.URL+"/upload/batch", uploads, nil, &mockConnection)
+		assert.False(t, supported)
+		assert.Nil(t, results)
+	})
+}
+
+func TestFailedUploads(t *testing.T) {
+	uploadedFiles := []UploadedFile{
+		{FilePath: "uploaded", Outcome: UploadOutcomeUploaded},
+		{FilePath: "retried", Outcome: UploadOutcomeRetried},
+		{FilePath: "skipped", Outcome: UploadOutcomeSkippedUnreadable},
+		{FilePath: "failed", Outcome: UploadOutcomeFailed},
+	}
+	assert.Equal(t, []UploadedFile{{FilePath: "failed", Outcome: UploadOutcomeFailed}}, FailedUploads(uploadedFiles))
+	assert.Equal(t, []UploadedFile{}, FailedUploads(nil))
+}
@@ -0,0 +1,247 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
+)
+
+const (
+	templateCacheEntryFile  = "entry.json"
+	templateCacheContentDir = "content"
+)
+
+// TemplateCacheEntry : A template saved in the local template cache, and the version it was saved at
+type TemplateCacheEntry struct {
+	URL      string `json:"url"`
+	Ref      string `json:"ref,omitempty"`
+	ETag     string `json:"etag,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	CachedAt int64  `json:"cachedAt"`
+	Path     string `json:"path"`
+}
+
+// TemplateCacheFailure : A template that could not be saved in the local template cache
+type TemplateCacheFailure struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// TemplateCacheResponse : The templates saved in the local template cache, and those that could not be
+type TemplateCacheResponse struct {
+	Cached []TemplateCacheEntry   `json:"cached"`
+	Failed []TemplateCacheFailure `json:"failed"`
+}
+
+// getTemplateCacheDir : Get the path to the directory templates are cached in
+func getTemplateCacheDir() string {
+	return path.Join(getCodewindHomeDir(), "templates")
+}
+
+// CacheTemplates : Download every template into the local template cache, carrying on past any that fail
+func CacheTemplates(urls []string, ref string, gitCredentials utils.GitCredentials) *TemplateCacheResponse {
+	return cacheTemplates(getTemplateCacheDir(), urls, ref, gitCredentials)
+}
+
+func cacheTemplates(cacheDir string, urls []string, ref string, gitCredentials utils.GitCredentials) *TemplateCacheResponse {
+	response := &TemplateCacheResponse{Cached: []TemplateCacheEntry{}, Failed: []TemplateCacheFailure{}}
+	for _, url := range urls {
+		entry, projErr := cacheTemplate(cacheDir, url, ref, gitCredentials)
+		if projErr != nil {
+			response.Failed = append(response.Failed, TemplateCacheFailure{URL: url, Error: projErr.Desc})
+			continue
+		}
+		response.Cached = append(response.Cached, *entry)
+	}
+	return response
+}
+
+// GetCachedTemplate : Get a template from the local template cache, returning nil if it has not been cached
+func GetCachedTemplate(url, ref string) (*TemplateCacheEntry, *ProjectError) {
+	return readTemplateCacheEntry(getTemplateCacheDir(), url, ref)
+}
+
+// templateCacheEntryDir returns the directory a template is cached in, which is keyed by its URL and ref
+func templateCacheEntryDir(cacheDir, url, ref string) string {
+	key := sha256.Sum256([]byte(url + "#" + ref))
+	return filepath.Join(cacheDir, hex.EncodeToString(key[:])[:16])
+}
+
+// cacheTemplate downloads a template into the cache, unless the cached copy is already up to date
+func cacheTemplate(cacheDir, url, ref string, gitCredentials utils.GitCredentials) (*TemplateCacheEntry, *ProjectError) {
+	cached, projErr := readTemplateCacheEntry(cacheDir, url, ref)
+	if projErr != nil {
+		return nil, projErr
+	}
+
+	// when the version cannot be found, the template is downloaded again rather than risk keeping a stale copy
+	etag, commit, err := utils.GetTemplateVersion(url, ref, gitCredentials)
+	if err != nil {
+		logr.Tracef("Unable to find the version of template %v: %v", url, err)
+	} else if cached != nil && (etag != "" || commit != "") && cached.ETag == etag && cached.Commit == commit {
+		logr.Tracef("Template %v is already cached at %v", url, cached.Path)
+		return cached, nil
+	}
+
+	entryDir := templateCacheEntryDir(cacheDir, url, ref)
+	err = os.MkdirAll(entryDir, 0777)
+	if err != nil {
+		return nil, &ProjectError{errOpTemplateCache, err, err.Error()}
+	}
+
+	// download beside the cached copy, so a failed download leaves the cached copy usable
+	downloadDir, err := ioutil.TempDir(entryDir, "download")
+	if err != nil {
+		return nil, &ProjectError{errOpTemplateCache, err, err.Error()}
+	}
+	defer os.RemoveAll(downloadDir)
+
+	err = utils.DownloadFromURLAtRefThenExtract(url, ref, downloadDir, gitCredentials)
+	if err != nil {
+		return nil, &ProjectError{errOpTemplateCache, err, err.Error()}
+	}
+
+	contentDir := filepath.Join(entryDir, templateCacheContentDir)
+	err = os.RemoveAll(contentDir)
+	if err == nil {
+		err = os.Rename(downloadDir, contentDir)
+	}
+	if err != nil {
+		return nil, &ProjectError{errOpTemplateCache, err, err.Error()}
+	}
+
+	entry := TemplateCacheEntry{
+		URL:      url,
+		Ref:      ref,
+		ETag:     etag,
+		Commit:   commit,
+		CachedAt: time.Now().UnixNano() / int64(time.Millisecond),
+		Path:     contentDir,
+	}
+	body, err := json.MarshalIndent(entry, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(entryDir, templateCacheEntryFile), body, 0644)
+	}
+	if err != nil {
+		return nil, &ProjectError{errOpTemplateCache, err, err.Error()}
+	}
+	return &entry, nil
+}
+
+// readTemplateCacheEntry reads the cache entry for a template, returning nil if it has not been cached
+func readTemplateCacheEntry(cacheDir, url, ref string) (*TemplateCacheEntry, *ProjectError) {
+	entryDir := templateCacheEntryDir(cacheDir, url, ref)
+	file, err := ioutil.ReadFile(filepath.Join(entryDir, templateCacheEntryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+
+	var entry TemplateCacheEntry
+	err = json.Unmarshal(file, &entry)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	// the entry is only usable if it is for this template and its content was saved
+	if entry.URL != url || entry.Ref != ref || !utils.PathExists(filepath.Join(entryDir, templateCacheContentDir)) {
+		return nil, nil
+	}
+	entry.Path = filepath.Join(entryDir, templateCacheContentDir)
+	return &entry, nil
+}
+
+// fetchTemplate puts a template into the cache if it can, then returns the cached copy.
+// Offline, only a template that is already cached is used. Online, a template whose server cannot be
+// reached falls back to the cached copy if there is one. Any other failure, such as bad credentials or
+// a URL or ref that does not exist, is returned rather than hidden behind a stale template.
+func fetchTemplate(cacheDir, url, ref string, gitCredentials utils.GitCredentials, offline bool) (*TemplateCacheEntry, *ProjectError) {
+	if !offline {
+		entry, projErr := cacheTemplate(cacheDir, url, ref, gitCredentials)
+		if projErr == nil {
+			return entry, nil
+		}
+		if _, isNetworkError := projErr.Err.(net.Error); !isNetworkError {
+			return nil, projErr
+		}
+		cached, _ := readTemplateCacheEntry(cacheDir, url, ref)
+		if cached == nil {
+			return nil, projErr
+		}
+		logr.Warnf("Unable to download template %v, using the copy cached at %v: %v", url, time.Unix(0, cached.CachedAt*int64(time.Millisecond)).Format(time.RFC3339), projErr.Desc)
+		return cached, nil
+	}
+
+	cached, projErr := readTemplateCacheEntry(cacheDir, url, ref)
+	if projErr != nil {
+		return nil, projErr
+	}
+	if cached == nil {
+		err := fmt.Errorf(textTemplateNotCached, url)
+		return nil, &ProjectError{errOpTemplateCache, err, err.Error()}
+	}
+	return cached, nil
+}
+
+// copyDirectory copies the contents of a directory into another, keeping file modes
+func copyDirectory(source, destination string) error {
+	return filepath.Walk(source, func(sourcePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(source, sourcePath)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(destination, relativePath)
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode()|0700)
+		}
+		if !info.Mode().IsRegular() {
+			return errors.New(sourcePath + " is not a regular file")
+		}
+		return copyFileWithMode(sourcePath, targetPath, info.Mode())
+	})
+}
+
+func copyFileWithMode(sourcePath, targetPath string, mode os.FileMode) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(target, source)
+	closeErr := target.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,234 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// templateServer serves a template as a tar.gz whose ETag is its version, counting downloads
+type templateServer struct {
+	server    *httptest.Server
+	files     map[string]string
+	version   string
+	downloads int
+	offline   bool
+}
+
+func newTemplateServer(version string, files map[string]string) *templateServer {
+	s := &templateServer{files: files, version: version}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *templateServer) url() string {
+	return s.server.URL + "/template.tar.gz"
+}
+
+func (s *templateServer) serve(w http.ResponseWriter, r *http.Request) {
+	if s.offline {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("ETag", `"`+s.version+`"`)
+	if r.Method == http.MethodHead {
+		return
+	}
+	s.downloads++
+
+	names := []string{}
+	dirs := map[string]bool{}
+	for name := range s.files {
+		names = append(names, name)
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	dirNames := []string{}
+	for dir := range dirs {
+		dirNames = append(dirNames, dir)
+	}
+	// parent directories sort before their children, so each is created before what is in it
+	sort.Strings(dirNames)
+	sort.Strings(names)
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	// archives made with tar -C template . start with the directory itself
+	tarWriter.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, dir := range dirNames {
+		tarWriter.WriteHeader(&tar.Header{Name: dir + "/", Typeflag: tar.TypeDir, Mode: 0755})
+	}
+	for _, name := range names {
+		content := s.files[name]
+		if name == "version" {
+			content = s.version
+		}
+		tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len(content))})
+		tarWriter.Write([]byte(content))
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+}
+
+// testTemplateFiles are the files of a template, with the version it was downloaded at written to version
+var testTemplateFiles = map[string]string{"package.json": `{"name": "[PROJ_NAME_PLACEHOLDER]"}`, "version": ""}
+
+func TestCacheTemplate(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "template_cache_test")
+	defer os.RemoveAll(cacheDir)
+	source := newTemplateServer("aaa", testTemplateFiles)
+	defer source.server.Close()
+
+	t.Run("success case - the template is downloaded on first use", func(t *testing.T) {
+		entry, err := cacheTemplate(cacheDir, source.url(), "", utils.GitCredentials{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, source.downloads)
+		assert.Equal(t, source.url(), entry.URL)
+		assert.Equal(t, `"aaa"`, entry.ETag)
+		assert.FileExists(t, filepath.Join(entry.Path, "package.json"))
+	})
+
+	t.Run("success case - an unchanged template is not downloaded again", func(t *testing.T) {
+		_, err := cacheTemplate(cacheDir, source.url(), "", utils.GitCredentials{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, source.downloads)
+	})
+
+	t.Run("success case - a changed template is refreshed", func(t *testing.T) {
+		source.version = "bbb"
+		entry, err := cacheTemplate(cacheDir, source.url(), "", utils.GitCredentials{})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, source.downloads)
+		content, _ := ioutil.ReadFile(filepath.Join(entry.Path, "version"))
+		assert.Equal(t, "bbb", string(content))
+	})
+
+	t.Run("success case - each ref is cached separately", func(t *testing.T) {
+		entry, err := cacheTemplate(cacheDir, source.url(), "v1.0", utils.GitCredentials{})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, source.downloads)
+		assert.Equal(t, "v1.0", entry.Ref)
+		assert.NotEqual(t, templateCacheEntryDir(cacheDir, source.url(), ""), templateCacheEntryDir(cacheDir, source.url(), "v1.0"))
+	})
+
+	t.Run("fail case - a failed download keeps the cached copy", func(t *testing.T) {
+		source.offline = true
+		defer func() { source.offline = false }()
+		_, err := cacheTemplate(cacheDir, source.url(), "", utils.GitCredentials{})
+		assert.Equal(t, errOpTemplateCache, err.Op)
+		cached, err := readTemplateCacheEntry(cacheDir, source.url(), "")
+		assert.Nil(t, err)
+		assert.Equal(t, `"bbb"`, cached.ETag)
+	})
+}
+
+func TestCacheTemplates(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "template_cache_test")
+	defer os.RemoveAll(cacheDir)
+	source := newTemplateServer("aaa", testTemplateFiles)
+	defer source.server.Close()
+	otherURL := source.server.URL + "/other/template.tar.gz"
+
+	t.Run("success case - every template is cached", func(t *testing.T) {
+		response := cacheTemplates(cacheDir, []string{source.url(), otherURL}, "", utils.GitCredentials{})
+		assert.Len(t, response.Cached, 2)
+		assert.Equal(t, []TemplateCacheFailure{}, response.Failed)
+	})
+
+	t.Run("fail case - every template is tried and the failures are reported", func(t *testing.T) {
+		response := cacheTemplates(cacheDir, []string{"not a url", source.url()}, "", utils.GitCredentials{})
+		assert.Len(t, response.Cached, 1)
+		assert.Equal(t, source.url(), response.Cached[0].URL)
+		assert.Equal(t, []string{"not a url"}, failedTemplateURLs(response))
+	})
+}
+
+// failedTemplateURLs returns the URL of each template that could not be cached
+func failedTemplateURLs(response *TemplateCacheResponse) []string {
+	urls := []string{}
+	for _, failure := range response.Failed {
+		urls = append(urls, failure.URL)
+	}
+	return urls
+}
+
+func TestCreateFromTemplateCache(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "template_cache_test")
+	defer os.RemoveAll(cacheDir)
+	source := newTemplateServer("aaa", testTemplateFiles)
+	defer source.server.Close()
+
+	t.Run("fail case - offline without a cached template", func(t *testing.T) {
+		destination := filepath.Join(cacheDir, "projects", "offline-uncached")
+		result, err := downloadTemplate(cacheDir, destination, source.url(), utils.GitCredentials{}, TemplateOptions{Offline: true})
+		assert.Nil(t, result)
+		assert.Equal(t, errOpCreateProject, err.Op)
+		assert.Contains(t, err.Desc, "has not been cached")
+		assert.Equal(t, 0, source.downloads)
+	})
+
+	t.Run("success case - online populates the cache and creates the project", func(t *testing.T) {
+		destination := filepath.Join(cacheDir, "projects", "online")
+		result, err := downloadTemplate(cacheDir, destination, source.url(), utils.GitCredentials{}, TemplateOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "success", result.Status)
+		content, _ := ioutil.ReadFile(filepath.Join(destination, "package.json"))
+		assert.Equal(t, `{"name": "online"}`, string(content))
+		info, _ := os.Stat(filepath.Join(destination, "version"))
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	})
+
+	t.Run("success case - offline uses the cached template", func(t *testing.T) {
+		source.offline = true
+		defer func() { source.offline = false }()
+		destination := filepath.Join(cacheDir, "projects", "offline-cached")
+		result, err := downloadTemplate(cacheDir, destination, source.url(), utils.GitCredentials{}, TemplateOptions{Offline: true})
+		assert.Nil(t, err)
+		assert.Equal(t, "success", result.Status)
+		assert.FileExists(t, filepath.Join(destination, "package.json"))
+	})
+
+	t.Run("success case - online falls back to the cached template when the server cannot be reached", func(t *testing.T) {
+		unreachable := newTemplateServer("aaa", testTemplateFiles)
+		_, err := downloadTemplate(cacheDir, filepath.Join(cacheDir, "projects", "before-fallback"), unreachable.url(), utils.GitCredentials{}, TemplateOptions{})
+		assert.Nil(t, err)
+		unreachable.server.Close()
+
+		destination := filepath.Join(cacheDir, "projects", "fallback")
+		result, err := downloadTemplate(cacheDir, destination, unreachable.url(), utils.GitCredentials{}, TemplateOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "success", result.Status)
+	})
+
+	t.Run("fail case - online reports a failed download rather than using the cached template", func(t *testing.T) {
+		source.offline = true
+		defer func() { source.offline = false }()
+		destination := filepath.Join(cacheDir, "projects", "no-fallback")
+		result, err := downloadTemplate(cacheDir, destination, source.url(), utils.GitCredentials{}, TemplateOptions{})
+		assert.Nil(t, result)
+		assert.Equal(t, errOpCreateProject, err.Op)
+		assert.Contains(t, err.Desc, "File download failed")
+	})
+}
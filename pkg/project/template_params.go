@@ -0,0 +1,185 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// templateManifestFile declares the parameters of a template, it is removed from projects created from the template
+const templateManifestFile = ".cw-template.json"
+
+// Template parameter types
+const (
+	TemplateParamString = "string"
+	TemplateParamInt    = "int"
+	TemplateParamBool   = "bool"
+)
+
+type (
+	// TemplateParameter : A value a template asks for when a project is created from it,
+	// substituted wherever [[name]] appears in the template's file contents and file names
+	TemplateParameter struct {
+		Name        string `json:"name"`
+		Type        string `json:"type,omitempty"`
+		Default     string `json:"default,omitempty"`
+		Validation  string `json:"validation,omitempty"` // a regular expression the whole value must match
+		Description string `json:"description,omitempty"`
+	}
+
+	// templateManifest represents the .cw-template.json file
+	templateManifest struct {
+		Parameters []TemplateParameter `json:"parameters"`
+	}
+
+	// TemplateParamPrompt asks the user for the value of a parameter that was not given
+	TemplateParamPrompt func(param TemplateParameter) (string, error)
+)
+
+var templateParamNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+// ParseTemplateParams : Parse key=value parameters given on the command line
+func ParseTemplateParams(params []string) (map[string]string, *ProjectError) {
+	values := make(map[string]string)
+	for _, param := range params {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			err := fmt.Errorf("invalid template parameter %q, expected key=value", param)
+			return nil, &ProjectError{errOpInvalidOptions, err, err.Error()}
+		}
+		values[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return values, nil
+}
+
+// templatePlaceholder returns the text a parameter replaces in a template
+func templatePlaceholder(name string) string {
+	return "[[" + name + "]]"
+}
+
+// readTemplateManifest reads the parameters a template declares, a template without a manifest has none
+func readTemplateManifest(templatePath string) (*templateManifest, *ProjectError) {
+	file, err := ioutil.ReadFile(filepath.Join(templatePath, templateManifestFile))
+	if os.IsNotExist(err) {
+		return &templateManifest{}, nil
+	}
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+
+	var manifest templateManifest
+	err = json.Unmarshal(file, &manifest)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+
+	seen := make(map[string]bool)
+	for i, param := range manifest.Parameters {
+		if !templateParamNameRegexp.MatchString(param.Name) || seen[param.Name] {
+			err := fmt.Errorf("%v declares an invalid or duplicate parameter name %q", templateManifestFile, param.Name)
+			return nil, &ProjectError{errOpFileParse, err, err.Error()}
+		}
+		seen[param.Name] = true
+		if param.Type == "" {
+			manifest.Parameters[i].Type = TemplateParamString
+		}
+		switch manifest.Parameters[i].Type {
+		case TemplateParamString, TemplateParamInt, TemplateParamBool:
+		default:
+			err := fmt.Errorf("%v declares parameter %v with unknown type %q", templateManifestFile, param.Name, param.Type)
+			return nil, &ProjectError{errOpFileParse, err, err.Error()}
+		}
+		if param.Validation != "" {
+			if _, err := regexp.Compile(anchoredPattern(param.Validation)); err != nil {
+				err = fmt.Errorf("%v declares parameter %v with invalid validation %q: %v", templateManifestFile, param.Name, param.Validation, err)
+				return nil, &ProjectError{errOpFileParse, err, err.Error()}
+			}
+		}
+	}
+	return &manifest, nil
+}
+
+// resolveTemplateParams works out the value of every parameter a template declares, from the given values,
+// then the prompt if there is one, then the parameter's default
+func resolveTemplateParams(manifest *templateManifest, given map[string]string, prompt TemplateParamPrompt) (map[string]string, *ProjectError) {
+	declared := make(map[string]bool)
+	for _, param := range manifest.Parameters {
+		declared[param.Name] = true
+	}
+	unknown := []string{}
+	for name := range given {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		err := fmt.Errorf("the template does not declare the parameters: %v", strings.Join(unknown, ", "))
+		return nil, &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+
+	values := make(map[string]string)
+	for _, param := range manifest.Parameters {
+		// an empty value is not given, as it is when the prompt is left blank
+		value := given[param.Name]
+		found := value != ""
+		if !found && prompt != nil {
+			prompted, err := prompt(param)
+			if err != nil {
+				return nil, &ProjectError{errOpInvalidOptions, err, err.Error()}
+			}
+			value, found = prompted, prompted != ""
+		}
+		if !found {
+			value = param.Default
+		}
+		if err := validateTemplateParam(param, value); err != nil {
+			return nil, &ProjectError{errOpInvalidOptions, err, err.Error()}
+		}
+		values[param.Name] = value
+	}
+	return values, nil
+}
+
+// validateTemplateParam checks a value has the parameter's type and matches its validation
+func validateTemplateParam(param TemplateParameter, value string) error {
+	if value == "" && param.Default == "" {
+		return fmt.Errorf("template parameter %v is required, give it with --param %v=<value>", param.Name, param.Name)
+	}
+	switch param.Type {
+	case TemplateParamInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("template parameter %v must be a whole number, but was %q", param.Name, value)
+		}
+	case TemplateParamBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("template parameter %v must be true or false, but was %q", param.Name, value)
+		}
+	}
+	if param.Validation != "" && !regexp.MustCompile(anchoredPattern(param.Validation)).MatchString(value) {
+		return fmt.Errorf("template parameter %v must match %v, but was %q", param.Name, param.Validation, value)
+	}
+	return nil
+}
+
+// anchoredPattern makes a validation pattern match the whole of a value, rather than any part of it
+func anchoredPattern(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
@@ -0,0 +1,173 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTemplateManifest = `{
+	"parameters": [
+		{"name": "groupId", "default": "com.example", "validation": "^[a-z][a-z0-9.]*$", "description": "Maven group ID"},
+		{"name": "port", "type": "int", "default": "9080"},
+		{"name": "description"}
+	]
+}`
+
+func TestParseTemplateParams(t *testing.T) {
+	t.Run("success case - values may contain =", func(t *testing.T) {
+		params, err := ParseTemplateParams([]string{"port=9090", "description=a=b"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"port": "9090", "description": "a=b"}, params)
+	})
+	t.Run("fail case - missing value", func(t *testing.T) {
+		_, err := ParseTemplateParams([]string{"port"})
+		assert.Equal(t, errOpInvalidOptions, err.Op)
+	})
+}
+
+func TestReadTemplateManifest(t *testing.T) {
+	tests := map[string]struct {
+		manifest string
+		wantErr  bool
+	}{
+		"valid manifest":     {manifest: testTemplateManifest},
+		"unknown type":       {manifest: `{"parameters": [{"name": "port", "type": "float"}]}`, wantErr: true},
+		"invalid name":       {manifest: `{"parameters": [{"name": "has space"}]}`, wantErr: true},
+		"duplicate name":     {manifest: `{"parameters": [{"name": "port"}, {"name": "port"}]}`, wantErr: true},
+		"invalid validation": {manifest: `{"parameters": [{"name": "port", "validation": "("}]}`, wantErr: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			templatePath, _ := ioutil.TempDir("", "template_params_test")
+			defer os.RemoveAll(templatePath)
+			ioutil.WriteFile(filepath.Join(templatePath, templateManifestFile), []byte(test.manifest), 0644)
+
+			manifest, err := readTemplateManifest(templatePath)
+			if test.wantErr {
+				assert.Equal(t, errOpFileParse, err.Op)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, TemplateParamString, manifest.Parameters[0].Type)
+			assert.Equal(t, TemplateParamInt, manifest.Parameters[1].Type)
+		})
+	}
+
+	t.Run("a template without a manifest has no parameters", func(t *testing.T) {
+		manifest, err := readTemplateManifest(os.TempDir())
+		assert.Nil(t, err)
+		assert.Empty(t, manifest.Parameters)
+	})
+}
+
+func TestResolveTemplateParams(t *testing.T) {
+	manifest := &templateManifest{Parameters: []TemplateParameter{
+		{Name: "groupId", Type: TemplateParamString, Default: "com.example", Validation: "^[a-z][a-z0-9.]*$"},
+		{Name: "port", Type: TemplateParamInt, Default: "9080"},
+		{Name: "description", Type: TemplateParamString},
+	}}
+
+	t.Run("success case - given values, then defaults", func(t *testing.T) {
+		values, err := resolveTemplateParams(manifest, map[string]string{"description": "My app"}, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"groupId": "com.example", "port": "9080", "description": "My app"}, values)
+	})
+
+	t.Run("success case - prompted values, blank answers use the default", func(t *testing.T) {
+		prompted := []string{}
+		prompt := func(param TemplateParameter) (string, error) {
+			prompted = append(prompted, param.Name)
+			if param.Name == "description" {
+				return "Prompted", nil
+			}
+			return "", nil
+		}
+		values, err := resolveTemplateParams(manifest, map[string]string{"port": "9090"}, prompt)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"groupId", "description"}, prompted)
+		assert.Equal(t, map[string]string{"groupId": "com.example", "port": "9090", "description": "Prompted"}, values)
+	})
+
+	t.Run("fail case - invalid values", func(t *testing.T) {
+		for given, wantDesc := range map[string]string{
+			"groupId": "template parameter groupId must match",
+			"port":    "template parameter port must be a whole number",
+		} {
+			_, err := resolveTemplateParams(manifest, map[string]string{given: "Not Valid", "description": "d"}, nil)
+			assert.Contains(t, err.Desc, wantDesc)
+		}
+	})
+
+	t.Run("success case - an empty value is not given, so the default is used", func(t *testing.T) {
+		values, err := resolveTemplateParams(manifest, map[string]string{"port": "", "description": "d"}, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "9080", values["port"])
+	})
+
+	t.Run("fail case - validation must match the whole value", func(t *testing.T) {
+		unanchored := &templateManifest{Parameters: []TemplateParameter{{Name: "version", Default: "1.0", Validation: "[0-9]+|[0-9]+\\.[0-9]+"}}}
+		values, err := resolveTemplateParams(unanchored, map[string]string{"version": "2.5"}, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "2.5", values["version"])
+		_, err = resolveTemplateParams(unanchored, map[string]string{"version": "2.5-beta"}, nil)
+		assert.Contains(t, err.Desc, "template parameter version must match")
+	})
+
+	t.Run("fail case - a required parameter is missing", func(t *testing.T) {
+		_, err := resolveTemplateParams(manifest, map[string]string{}, nil)
+		assert.Contains(t, err.Desc, "template parameter description is required")
+	})
+
+	t.Run("fail case - an undeclared parameter is given", func(t *testing.T) {
+		_, err := resolveTemplateParams(manifest, map[string]string{"description": "d", "version": "1", "artifactId": "a"}, nil)
+		assert.Equal(t, "the template does not declare the parameters: artifactId, version", err.Desc)
+	})
+}
+
+func TestCreateWithTemplateParams(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "template_params_test")
+	defer os.RemoveAll(cacheDir)
+	source := newTemplateServer("aaa", map[string]string{
+		templateManifestFile: testTemplateManifest,
+		"Dockerfile":         "EXPOSE [[port]]",
+		"src/main/java/[[groupId]]/[PROJ_NAME_PLACEHOLDER].java": "package [[groupId]]; // [[description]]",
+	})
+	defer source.server.Close()
+
+	t.Run("success case - parameters are substituted in file contents and names", func(t *testing.T) {
+		destination := filepath.Join(cacheDir, "projects", "myapp")
+		options := TemplateOptions{Params: map[string]string{"groupId": "org.acme", "description": "An app"}}
+		_, err := downloadTemplate(cacheDir, destination, source.url(), utils.GitCredentials{}, options)
+		assert.Nil(t, err)
+
+		content, _ := ioutil.ReadFile(filepath.Join(destination, "src", "main", "java", "org.acme", "myapp.java"))
+		assert.Equal(t, "package org.acme; // An app", string(content))
+		content, _ = ioutil.ReadFile(filepath.Join(destination, "Dockerfile"))
+		assert.Equal(t, "EXPOSE 9080", string(content))
+		assert.False(t, utils.PathExists(filepath.Join(destination, templateManifestFile)))
+	})
+
+	t.Run("fail case - nothing is created when a parameter is invalid", func(t *testing.T) {
+		destination := filepath.Join(cacheDir, "projects", "invalid")
+		options := TemplateOptions{Params: map[string]string{"port": "http", "description": "An app"}}
+		_, err := downloadTemplate(cacheDir, destination, source.url(), utils.GitCredentials{}, options)
+		assert.Equal(t, errOpCreateProject, err.Op)
+		assert.False(t, utils.PathExists(destination))
+	})
+}
@@ -0,0 +1,154 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// The states a project can be waited for
+const (
+	WaitForRunning = "running"
+	WaitForBuilt   = "built"
+	WaitForStopped = "stopped"
+)
+
+// DefaultWaitTimeout : How long to wait for a project when no timeout is given
+const DefaultWaitTimeout = 5 * time.Minute
+
+type (
+	// WaitTransition : A change in the status of a project while it was waited for
+	WaitTransition struct {
+		Time        string `json:"time"`
+		AppStatus   string `json:"appStatus"`
+		BuildStatus string `json:"buildStatus"`
+	}
+
+	// WaitResponse : The result of waiting for a project
+	WaitResponse struct {
+		ProjectID   string           `json:"projectID"`
+		For         string           `json:"for"`
+		Reached     bool             `json:"reached"`
+		AppStatus   string           `json:"appStatus"`
+		BuildStatus string           `json:"buildStatus"`
+		Transitions []WaitTransition `json:"transitions"`
+	}
+)
+
+// waitPollInterval is how often PFE is asked for the status of a project being waited for
+var waitPollInterval = 2 * time.Second
+
+// WaitForProject : Wait until the project given by --id reaches the state given by --for, calling onTransition each time its status changes.
+// Waiting for a project to be running or built fails as soon as its build fails, and any wait fails when --timeout has passed.
+// The build status the project has when waiting starts is left from an earlier build, so a project is only built, or its build
+// only fails, once its build status has changed.
+func WaitForProject(c *cli.Context, onTransition func(WaitTransition)) (*WaitResponse, *ProjectError) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	state := strings.TrimSpace(strings.ToLower(c.String("for")))
+	timeout := DefaultWaitTimeout
+	if c.IsSet("timeout") {
+		timeout = c.Duration("timeout")
+	}
+
+	if state != WaitForRunning && state != WaitForBuilt && state != WaitForStopped {
+		err := fmt.Errorf("unknown state %v, a project can be waited for until it is %v, %v or %v", state, WaitForRunning, WaitForBuilt, WaitForStopped)
+		return nil, &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+	if timeout <= 0 {
+		err := errors.New("the timeout must be longer than 0")
+		return nil, &ProjectError{errOpInvalidOptions, err, err.Error()}
+	}
+
+	// without a connection, the one the project is bound to is used
+	var projErr *ProjectError
+	if conID == "" {
+		conID, projErr = GetConnectionID(projectID)
+		if projErr != nil {
+			return nil, projErr
+		}
+	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return waitForProject(http.DefaultClient, conInfo, conURL, projectID, state, timeout, onTransition)
+}
+
+// waitForProject polls the status of a project until it reaches the state, its build fails or the timeout passes
+func waitForProject(httpClient utils.HTTPClient, conInfo *connections.Connection, conURL string, projectID string, state string, timeout time.Duration, onTransition func(WaitTransition)) (*WaitResponse, *ProjectError) {
+	deadline := time.Now().Add(timeout)
+	response := &WaitResponse{ProjectID: projectID, For: state, Transitions: []WaitTransition{}}
+	startBuildStatus := ""
+	newBuild := false
+	for {
+		project, projErr := GetProjectFromID(httpClient, conInfo, conURL, projectID)
+		if projErr != nil {
+			return response, projErr
+		}
+
+		if len(response.Transitions) == 0 {
+			startBuildStatus = project.BuildStatus
+		}
+		if len(response.Transitions) == 0 || project.AppStatus != response.AppStatus || project.BuildStatus != response.BuildStatus {
+			transition := WaitTransition{Time: time.Now().UTC().Format(time.RFC3339), AppStatus: project.AppStatus, BuildStatus: project.BuildStatus}
+			response.Transitions = append(response.Transitions, transition)
+			onTransition(transition)
+		}
+		response.AppStatus, response.BuildStatus = project.AppStatus, project.BuildStatus
+		newBuild = newBuild || project.BuildStatus != startBuildStatus
+
+		if hasReachedState(project, state, newBuild) {
+			response.Reached = true
+			return response, nil
+		}
+		if state != WaitForStopped && newBuild && project.BuildStatus == "failed" {
+			err := errors.New("the build of project " + projectID + " failed")
+			if project.DetailedBuildStatus != "" {
+				err = fmt.Errorf("%v: %v", err, project.DetailedBuildStatus)
+			}
+			return response, &ProjectError{errOpWaitFailed, err, err.Error()}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			err := fmt.Errorf("project %v was not %v after %v", projectID, state, timeout)
+			return response, &ProjectError{errOpWaitTimeout, err, err.Error()}
+		}
+		if remaining > waitPollInterval {
+			remaining = waitPollInterval
+		}
+		time.Sleep(remaining)
+	}
+}
+
+// hasReachedState returns true if the statuses of a project show it is in the state,
+// where it is only built by a build that finished after waiting started
+func hasReachedState(project *Project, state string, newBuild bool) bool {
+	switch state {
+	case WaitForRunning:
+		return project.AppStatus == "started"
+	case WaitForBuilt:
+		return newBuild && project.BuildStatus == "success"
+	case WaitForStopped:
+		return project.AppStatus == "stopped"
+	}
+	return false
+}
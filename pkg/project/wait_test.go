@@ -0,0 +1,120 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+// clientMockProjectStatuses answers each request for a project with the next of its statuses, repeating the last one
+type clientMockProjectStatuses struct {
+	statuses []Project
+	requests int
+}
+
+func (c *clientMockProjectStatuses) Do(req *http.Request) (*http.Response, error) {
+	status := c.statuses[len(c.statuses)-1]
+	if c.requests < len(c.statuses) {
+		status = c.statuses[c.requests]
+	}
+	c.requests++
+	body, _ := json.Marshal(status)
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func TestWaitForProject(t *testing.T) {
+	originalInterval := waitPollInterval
+	defer func() { waitPollInterval = originalInterval }()
+	waitPollInterval = time.Millisecond
+	mockConnection := &connections.Connection{ID: "local"}
+
+	statuses := func(statuses ...string) []Project {
+		projects := []Project{}
+		for i := 0; i < len(statuses); i += 2 {
+			projects = append(projects, Project{ProjectID: "abc123", BuildStatus: statuses[i], AppStatus: statuses[i+1]})
+		}
+		return projects
+	}
+	transitions := func(response *WaitResponse) []string {
+		changes := []string{}
+		for _, transition := range response.Transitions {
+			assert.NotEmpty(t, transition.Time)
+			changes = append(changes, transition.BuildStatus+"/"+transition.AppStatus)
+		}
+		return changes
+	}
+
+	t.Run("success case - running once built and started, reporting each change", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: statuses("inProgress", "stopped", "inProgress", "stopped", "success", "starting", "success", "started")}
+		changes := []WaitTransition{}
+		response, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForRunning, time.Minute, func(transition WaitTransition) {
+			changes = append(changes, transition)
+		})
+		assert.Nil(t, err)
+		assert.True(t, response.Reached)
+		assert.Equal(t, []string{"inProgress/stopped", "success/starting", "success/started"}, transitions(response))
+		assert.Equal(t, response.Transitions, changes)
+		assert.Equal(t, 4, client.requests)
+	})
+
+	t.Run("success case - built does not wait for the app to start", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: statuses("inProgress", "stopped", "success", "starting")}
+		response, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForBuilt, time.Minute, func(WaitTransition) {})
+		assert.Nil(t, err)
+		assert.Equal(t, "success", response.BuildStatus)
+	})
+
+	t.Run("success case - built waits for a new build when the last one succeeded", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: statuses("success", "started", "success", "started", "inProgress", "started", "success", "started")}
+		response, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForBuilt, time.Minute, func(WaitTransition) {})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"success/started", "inProgress/started", "success/started"}, transitions(response))
+		assert.Equal(t, 4, client.requests)
+	})
+
+	t.Run("success case - running waits for a new build when the last one failed", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: statuses("failed", "stopped", "inProgress", "stopped", "success", "started")}
+		response, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForRunning, time.Minute, func(WaitTransition) {})
+		assert.Nil(t, err)
+		assert.True(t, response.Reached)
+		assert.Equal(t, []string{"failed/stopped", "inProgress/stopped", "success/started"}, transitions(response))
+	})
+
+	t.Run("fail case - the build fails", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: []Project{{BuildStatus: "inProgress"}, {BuildStatus: "failed", DetailedBuildStatus: "Dockerfile not found"}}}
+		response, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForRunning, time.Minute, func(WaitTransition) {})
+		assert.Equal(t, errOpWaitFailed, err.Op)
+		assert.Equal(t, "the build of project abc123 failed: Dockerfile not found", err.Desc)
+		assert.False(t, response.Reached)
+	})
+
+	t.Run("fail case - a build that already failed times out rather than failing", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: statuses("failed", "stopped")}
+		_, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForBuilt, 10*time.Millisecond, func(WaitTransition) {})
+		assert.Equal(t, errOpWaitTimeout, err.Op)
+	})
+
+	t.Run("fail case - the timeout passes", func(t *testing.T) {
+		client := &clientMockProjectStatuses{statuses: statuses("success", "stopped")}
+		response, err := waitForProject(client, mockConnection, "http://pfe", "abc123", WaitForRunning, 10*time.Millisecond, func(WaitTransition) {})
+		assert.Equal(t, errOpWaitTimeout, err.Op)
+		assert.Equal(t, []string{"success/stopped"}, transitions(response))
+	})
+}
@@ -0,0 +1,283 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// watchDebounce is how long the project must be quiet before changes are synced
+const watchDebounce = 500 * time.Millisecond
+
+// projectWatcher watches every directory of a project that is not ignored, and the paths outside
+// the project that it references in .cw-refpaths.json
+type projectWatcher struct {
+	projectPath string
+	options     SyncOptions
+	ignore      *ignoreMatcher
+	debounce    time.Duration
+	watcher     *fsnotify.Watcher
+	refPatterns []string // the referenced paths outside the project, which may be glob patterns
+	refWatches  []string // the directories outside the project watched for the referenced paths
+}
+
+// WatchProject : Sync a project, then sync it again each time its files change until interrupted.
+// onSync is called with the result of every sync.
+func WatchProject(c *cli.Context, onSync func(*SyncResponse, *ProjectError)) *ProjectError {
+	projectPath := strings.TrimSpace(c.String("path"))
+	projectID := strings.TrimSpace(c.String("id"))
+	synctime := int64(c.Int("time"))
+	options := syncOptionsFromContext(c)
+
+	conID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return projErr
+	}
+	conInfo, conURL, projErr := GetConnectionAndURL(conID)
+	if projErr != nil {
+		return projErr
+	}
+
+	watcher, projErr := newProjectWatcher(projectPath, options, watchDebounce)
+	if projErr != nil {
+		return projErr
+	}
+	defer watcher.close()
+
+	// start watching before the first sync, so no changes made during it are missed
+	lastSyncTime := time.Now().UnixNano() / 1000000
+	onSync(syncProject(projectPath, projectID, conInfo, conURL, synctime, options))
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	return watcher.run(stop, func() {
+		synctime := lastSyncTime
+		lastSyncTime = time.Now().UnixNano() / 1000000
+		onSync(syncProject(projectPath, projectID, conInfo, conURL, synctime, options))
+	})
+}
+
+// newProjectWatcher starts watching every directory in the project that is not ignored
+func newProjectWatcher(projectPath string, options SyncOptions, debounce time.Duration) (*projectWatcher, *ProjectError) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, &ProjectError{errOpWatch, err, err.Error()}
+	}
+
+	w := &projectWatcher{
+		projectPath: filepath.Clean(projectPath),
+		options:     options,
+		ignore:      newProjectIgnoreMatcher(projectPath, options),
+		debounce:    debounce,
+		watcher:     watcher,
+	}
+
+	err = w.addDirectories(w.projectPath)
+	if err != nil {
+		watcher.Close()
+		return nil, &ProjectError{errOpWatch, err, err.Error()}
+	}
+	w.watchRefPaths()
+	return w, nil
+}
+
+func (w *projectWatcher) close() {
+	w.watcher.Close()
+}
+
+// addDirectories watches root and every directory beneath it that is not ignored,
+// as file system notifications are not recursive
+func (w *projectWatcher) addDirectories(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if w.isIgnored(path, true) {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// watchRefPaths watches the paths outside the project that .cw-refpaths.json references,
+// replacing those watched before
+func (w *projectWatcher) watchRefPaths() {
+	for _, dir := range w.refWatches {
+		w.watcher.Remove(dir)
+	}
+	w.refPatterns = []string{}
+	w.refWatches = []string{}
+
+	for _, ref := range retrieveRefPathsList(w.projectPath) {
+		sources, err := expandRefPath(w.projectPath, ref)
+		if err != nil {
+			logr.Warnf("Unable to watch file reference %q: %v", ref.From, err)
+			continue
+		}
+		from := ref.From
+		if !filepath.IsAbs(from) {
+			from = filepath.Join(w.projectPath, from)
+		}
+		from = filepath.Clean(from)
+		// paths inside the project are already watched
+		if w.isInProject(from) {
+			continue
+		}
+		w.refPatterns = append(w.refPatterns, from)
+
+		// the parent is watched so a file that is replaced, or newly matches a pattern, is seen
+		w.addRefDirectory(filepath.Dir(from))
+		for _, source := range sources {
+			if source.IsDir() {
+				w.addRefDirectories(source.From)
+			}
+		}
+	}
+}
+
+// addRefDirectories watches a referenced directory and every directory beneath it,
+// skipping any that cannot be read as the sync does
+func (w *projectWatcher) addRefDirectories(root string) {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			w.addRefDirectory(path)
+		}
+		return nil
+	})
+}
+
+func (w *projectWatcher) addRefDirectory(dir string) {
+	err := w.watcher.Add(dir)
+	if err != nil {
+		logr.Warnf("Unable to watch %v: %v", dir, err)
+		return
+	}
+	w.refWatches = append(w.refWatches, dir)
+}
+
+// isInProject returns true if the path is the project or inside it
+func (w *projectWatcher) isInProject(path string) bool {
+	relativePath, err := filepath.Rel(w.projectPath, path)
+	if err != nil {
+		return false
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	return relativePath != ".." && !strings.HasPrefix(relativePath, "../")
+}
+
+// isReferenced returns true if a path outside the project is a referenced path, or is inside one
+func (w *projectWatcher) isReferenced(path string) bool {
+	for {
+		for _, pattern := range w.refPatterns {
+			if matched, _ := filepath.Match(pattern, path); matched {
+				return true
+			}
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+		path = parent
+	}
+}
+
+// isIgnored returns true if changes to the path should not trigger a sync
+func (w *projectWatcher) isIgnored(path string, isDir bool) bool {
+	if path == w.projectPath {
+		return false
+	}
+	if !w.isInProject(path) {
+		return !w.isReferenced(path)
+	}
+	relativePath, err := filepath.Rel(w.projectPath, path)
+	if err != nil {
+		return true
+	}
+	return w.ignore.isIgnored(filepath.ToSlash(relativePath), isDir)
+}
+
+// run calls onChange once the project has been quiet for the debounce time after a change,
+// until stop is closed
+func (w *projectWatcher) run(stop <-chan struct{}, onChange func()) *ProjectError {
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			info, err := os.Stat(event.Name)
+			isDir := err == nil && info.IsDir()
+			if w.isIgnored(event.Name, isDir) {
+				continue
+			}
+
+			// new directories need watching too, and changed settings may change what is ignored or referenced
+			if isDir && event.Op&fsnotify.Create == fsnotify.Create {
+				if w.isInProject(event.Name) {
+					err := w.addDirectories(event.Name)
+					if err != nil {
+						logr.Warnf("Unable to watch %v: %v", event.Name, err)
+					}
+				} else {
+					w.addRefDirectories(event.Name)
+				}
+			}
+			if filepath.Dir(event.Name) == w.projectPath && isIgnoreFile(filepath.Base(event.Name)) {
+				w.ignore = newProjectIgnoreMatcher(w.projectPath, w.options)
+			}
+			if event.Name == filepath.Join(w.projectPath, ".cw-refpaths.json") {
+				w.watchRefPaths()
+			}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logr.Warnf("Error watching project %v: %v", w.projectPath, err)
+
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
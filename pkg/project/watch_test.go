@@ -0,0 +1,147 @@
+/*******************************************************************************
+ * Copyright (c) 2020 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWatchDebounce = 100 * time.Millisecond
+
+// startTestWatcher runs a projectWatcher on projectPath, returning a channel that receives each change
+// and a function that stops the watcher
+func startTestWatcher(t *testing.T, projectPath string) (chan struct{}, func()) {
+	watcher, err := newProjectWatcher(projectPath, SyncOptions{}, testWatchDebounce)
+	if err != nil {
+		t.Fatalf("newProjectWatcher() failed with error: %s", err.Desc)
+	}
+	changes := make(chan struct{}, 10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watcher.run(stop, func() { changes <- struct{}{} })
+		watcher.close()
+		close(done)
+	}()
+	return changes, func() {
+		close(stop)
+		<-done
+	}
+}
+
+// countChanges returns the number of changes received before the project has been quiet for a while
+func countChanges(changes chan struct{}) int {
+	count := 0
+	for {
+		select {
+		case <-changes:
+			count++
+		case <-time.After(5 * testWatchDebounce):
+			return count
+		}
+	}
+}
+
+func TestProjectWatcher(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "watch_test")
+	defer os.RemoveAll(testDir)
+
+	cwSettings, _ := json.Marshal(CWSettings{IgnoredPaths: []string{"ignored.txt"}})
+	ioutil.WriteFile(filepath.Join(testDir, ".cw-settings"), cwSettings, 0644)
+	os.Mkdir(filepath.Join(testDir, "src"), 0777)
+	os.Mkdir(filepath.Join(testDir, "node_modules"), 0777)
+
+	changes, stop := startTestWatcher(t, testDir)
+	defer stop()
+
+	t.Run("a burst of changes is synced once", func(t *testing.T) {
+		for _, name := range []string{"a", "b", "c"} {
+			ioutil.WriteFile(filepath.Join(testDir, "src", name), []byte(name), 0644)
+		}
+		assert.Equal(t, 1, countChanges(changes))
+	})
+
+	t.Run("changes to ignored files are not synced", func(t *testing.T) {
+		ioutil.WriteFile(filepath.Join(testDir, "ignored.txt"), []byte{}, 0644)
+		ioutil.WriteFile(filepath.Join(testDir, ".DS_Store"), []byte{}, 0644)
+		ioutil.WriteFile(filepath.Join(testDir, "node_modules", "module.js"), []byte{}, 0644)
+		assert.Equal(t, 0, countChanges(changes))
+	})
+
+	t.Run("files in new directories are watched", func(t *testing.T) {
+		newDir := filepath.Join(testDir, "src", "new")
+		os.Mkdir(newDir, 0777)
+		assert.Equal(t, 1, countChanges(changes))
+
+		ioutil.WriteFile(filepath.Join(newDir, "file"), []byte{}, 0644)
+		assert.Equal(t, 1, countChanges(changes))
+	})
+
+	t.Run("changes to .cw-settings update the ignored paths", func(t *testing.T) {
+		cwSettings, _ := json.Marshal(CWSettings{IgnoredPaths: []string{"ignored.txt", "alsoignored.txt"}})
+		ioutil.WriteFile(filepath.Join(testDir, ".cw-settings"), cwSettings, 0644)
+		assert.Equal(t, 1, countChanges(changes))
+
+		ioutil.WriteFile(filepath.Join(testDir, "alsoignored.txt"), []byte{}, 0644)
+		assert.Equal(t, 0, countChanges(changes))
+	})
+}
+
+func TestProjectWatcherRefPaths(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "watch_refpaths_test")
+	defer os.RemoveAll(testDir)
+
+	projectPath := filepath.Join(testDir, "project")
+	sharedPath := filepath.Join(testDir, "shared")
+	os.MkdirAll(projectPath, 0777)
+	os.MkdirAll(filepath.Join(sharedPath, "config"), 0777)
+	ioutil.WriteFile(filepath.Join(sharedPath, "config", "app.json"), []byte("{}"), 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "other.txt"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(sharedPath, "later.txt"), []byte{}, 0644)
+
+	writeRefPaths := func(refs ...refPath) {
+		body, _ := json.Marshal(refPaths{RefPaths: refs})
+		ioutil.WriteFile(filepath.Join(projectPath, ".cw-refpaths.json"), body, 0644)
+	}
+	writeRefPaths(refPath{From: "../shared/config", To: "config"})
+
+	changes, stop := startTestWatcher(t, projectPath)
+	defer stop()
+
+	t.Run("changes to referenced directories outside the project are synced", func(t *testing.T) {
+		ioutil.WriteFile(filepath.Join(sharedPath, "config", "app.json"), []byte(`{"changed":true}`), 0644)
+		assert.Equal(t, 1, countChanges(changes))
+	})
+
+	t.Run("changes next to a referenced path are not synced", func(t *testing.T) {
+		ioutil.WriteFile(filepath.Join(sharedPath, "other.txt"), []byte("changed"), 0644)
+		assert.Equal(t, 0, countChanges(changes))
+	})
+
+	t.Run("changes to .cw-refpaths.json update the referenced paths", func(t *testing.T) {
+		writeRefPaths(refPath{From: "../shared/later.txt", To: "later.txt"})
+		assert.Equal(t, 1, countChanges(changes))
+
+		ioutil.WriteFile(filepath.Join(sharedPath, "later.txt"), []byte("changed"), 0644)
+		assert.Equal(t, 1, countChanges(changes))
+
+		ioutil.WriteFile(filepath.Join(sharedPath, "config", "app.json"), []byte("{}"), 0644)
+		assert.Equal(t, 0, countChanges(changes))
+	})
+}
@@ -174,3 +174,15 @@ func testDispatchHTTPRequest(t *testing.T) {
 		security.DeleteSecretFromKeyring(connectionID, mockConnectionUsername)
 	})
 }
+
+func TestIsConnectionError(t *testing.T) {
+	t.Run("Local request that cannot reach the server is a connection error", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://localhost", nil)
+		_, err := DispatchHTTPRequest(&security.ClientMockRequestFail{}, req, &connections.Connection{ID: "local"})
+		assert.True(t, err.IsConnectionError())
+	})
+	t.Run("Missing password is not a connection error", func(t *testing.T) {
+		err := &HTTPSecError{errOpNoPassword, errors.New(errMissingPassword), errMissingPassword}
+		assert.False(t, err.IsConnectionError())
+	})
+}
@@ -43,3 +43,9 @@ func (se *HTTPSecError) Error() string {
 	jsonError, _ := json.Marshal(tempOutput)
 	return string(jsonError)
 }
+
+// IsConnectionError : True if the request failed because the server could not be reached,
+// rather than because the user could not be authenticated
+func (se *HTTPSecError) IsConnectionError() bool {
+	return se.Op == errOpNoConnection
+}
@@ -37,18 +37,67 @@ type (
 // DownloadFromURLThenExtract downloads files from a URL
 // to a destination, extracting them if necessary
 func DownloadFromURLThenExtract(inURL, destination string, gitCredentials GitCredentials) error {
-	URL, err := url.ParseRequestURI(inURL)
+	return DownloadFromURLAtRefThenExtract(inURL, "", destination, gitCredentials)
+}
+
+// DownloadFromURLAtRefThenExtract downloads files from a URL to a destination, extracting them if necessary.
+// For repo URLs, ref is the branch, tag or commit to download, and defaults to master.
+func DownloadFromURLAtRefThenExtract(inURL, ref, destination string, gitCredentials GitCredentials) error {
+	URL, err := parseAbsoluteURL(inURL)
 	if err != nil {
 		return err
 	}
-	if !URL.IsAbs() {
-		return fmt.Errorf("URL must be absolute, but received relative URL %s", URL)
-	}
 
 	if IsTarGzURL(URL) {
 		return DownloadFromTarGzURL(URL, destination, gitCredentials)
 	}
-	return DownloadFromRepoURL(URL, destination, gitCredentials)
+	return DownloadFromRepoURLAtRef(URL, ref, destination, gitCredentials)
+}
+
+// GetTemplateVersion returns the version of the content at a URL, so a copy of it can be checked for changes.
+// For tar.gz URLs this is the ETag the server returns, and for repo URLs it is the commit ref points to.
+func GetTemplateVersion(inURL, ref string, gitCredentials GitCredentials) (etag string, commit string, err error) {
+	URL, err := parseAbsoluteURL(inURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	if IsTarGzURL(URL) {
+		resp, err := http.Head(URL.String())
+		if err != nil {
+			return "", "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", "", fmt.Errorf("unexpected status code: %v", resp.Status)
+		}
+		return resp.Header.Get("ETag"), "", nil
+	}
+
+	owner, repo, err := getRepoOwnerAndName(URL)
+	if err != nil {
+		return "", "", err
+	}
+	client, err := getGitHubClient(URL.Host, gitCredentials)
+	if err != nil {
+		return "", "", err
+	}
+	commit, _, err = client.Repositories.GetCommitSHA1(context.Background(), owner, repo, refOrMaster(ref), "")
+	if err != nil {
+		return "", "", err
+	}
+	return "", commit, nil
+}
+
+func parseAbsoluteURL(inURL string) (*url.URL, error) {
+	URL, err := url.ParseRequestURI(inURL)
+	if err != nil {
+		return nil, err
+	}
+	if !URL.IsAbs() {
+		return nil, fmt.Errorf("URL must be absolute, but received relative URL %s", URL)
+	}
+	return URL, nil
 }
 
 // DownloadFromTarGzURL downloads a tar.gz file from a URL
@@ -136,10 +185,14 @@ func findAssetID(releases []*github.RepositoryRelease, releaseName string, URL *
 
 // DownloadFromRepoURL downloads a repo from a URL to a destination
 func DownloadFromRepoURL(URL *url.URL, destination string, gitCredentials GitCredentials) error {
-	URLPathSlice := strings.Split(URL.Path, "/")
+	return DownloadFromRepoURLAtRef(URL, "master", destination, gitCredentials)
+}
 
-	if !strings.Contains(URL.Host, "github") || len(URLPathSlice) < 3 {
-		return fmt.Errorf("URL must point to a GitHub repository release asset: %v", URL)
+// DownloadFromRepoURLAtRef downloads a repo at a branch, tag or commit from a URL to a destination
+func DownloadFromRepoURLAtRef(URL *url.URL, ref, destination string, gitCredentials GitCredentials) error {
+	owner, repo, err := getRepoOwnerAndName(URL)
+	if err != nil {
+		return err
 	}
 
 	client, err := getGitHubClient(URL.Host, gitCredentials)
@@ -147,9 +200,7 @@ func DownloadFromRepoURL(URL *url.URL, destination string, gitCredentials GitCre
 		return err
 	}
 
-	owner := URLPathSlice[1]
-	repo := URLPathSlice[2]
-	zipURL, err := GetZipURL(owner, repo, "master", client)
+	zipURL, err := GetZipURL(owner, repo, refOrMaster(ref), client)
 	if err != nil {
 		return err
 	}
@@ -157,6 +208,23 @@ func DownloadFromRepoURL(URL *url.URL, destination string, gitCredentials GitCre
 	return DownloadAndExtractZip(zipURL, destination)
 }
 
+func getRepoOwnerAndName(URL *url.URL) (string, string, error) {
+	URLPathSlice := strings.Split(URL.Path, "/")
+
+	if !strings.Contains(URL.Host, "github") || len(URLPathSlice) < 3 {
+		return "", "", fmt.Errorf("URL must point to a GitHub repository release asset: %v", URL)
+	}
+	// clone URLs name the repository with a .git suffix
+	return URLPathSlice[1], strings.TrimSuffix(URLPathSlice[2], ".git"), nil
+}
+
+func refOrMaster(ref string) string {
+	if ref == "" {
+		return "master"
+	}
+	return ref
+}
+
 func getGitHubClient(domain string, gitCredentials GitCredentials) (*github.Client, error) {
 	if gitCredentials == (GitCredentials{}) {
 		return github.NewClient(nil), nil
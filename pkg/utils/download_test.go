@@ -15,6 +15,8 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -338,6 +340,32 @@ func TestDownloadFile(t *testing.T) {
 	})
 }
 
+func TestGetTemplateVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/template.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"abc123"`)
+	}))
+	defer server.Close()
+
+	t.Run("success case - tar.gz URLs are versioned by their ETag", func(t *testing.T) {
+		etag, commit, err := GetTemplateVersion(server.URL+"/template.tar.gz", "", GitCredentials{})
+		assert.Nil(t, err)
+		assert.Equal(t, `"abc123"`, etag)
+		assert.Equal(t, "", commit)
+	})
+	t.Run("fail case - missing tar.gz", func(t *testing.T) {
+		_, _, err := GetTemplateVersion(server.URL+"/missing.tar.gz", "", GitCredentials{})
+		assert.Contains(t, err.Error(), "unexpected status code")
+	})
+	t.Run("fail case - relative URL", func(t *testing.T) {
+		_, _, err := GetTemplateVersion("/template.tar.gz", "", GitCredentials{})
+		assert.Contains(t, err.Error(), "URL must be absolute")
+	})
+}
+
 func TestIsTarGzURL(t *testing.T) {
 	tests := map[string]struct {
 		in   *url.URL
@@ -368,3 +396,35 @@ func toURL(inURL string) *url.URL {
 	URL, _ := url.ParseRequestURI(inURL)
 	return URL
 }
+
+func TestGetRepoOwnerAndName(t *testing.T) {
+	tests := map[string]struct {
+		in        string
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		"success case: repo URL": {
+			in:        "https://github.com/codewind-resources/nodeExpressTemplate",
+			wantOwner: "codewind-resources",
+			wantRepo:  "nodeExpressTemplate",
+		},
+		"success case: clone URL": {
+			in:        "https://github.com/codewind-resources/nodeExpressTemplate.git",
+			wantOwner: "codewind-resources",
+			wantRepo:  "nodeExpressTemplate",
+		},
+		"fail case: not GitHub": {
+			in:      "https://example.com/codewind-resources/nodeExpressTemplate",
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			owner, repo, err := getRepoOwnerAndName(toURL(test.in))
+			assert.Equal(t, test.wantErr, err != nil)
+			assert.Equal(t, test.wantOwner, owner)
+			assert.Equal(t, test.wantRepo, repo)
+		})
+	}
+}
@@ -23,10 +23,19 @@ import (
 type (
 	// Extension represents a project extension defined by codewind.yaml
 	Extension struct {
-		ProjectType string             `json:"projectType"`
-		Detection   string             `json:"detection"`
-		Commands    []ExtensionCommand `json:"commands"`
-		Config      ExtensionConfig    `json:"config"`
+		ProjectType string              `json:"projectType"`
+		Detection   string              `json:"detection"`
+		Commands    []ExtensionCommand  `json:"commands"`
+		Config      ExtensionConfig     `json:"config"`
+		Detectors   []ExtensionDetector `json:"detectors,omitempty"`
+	}
+
+	// ExtensionDetector describes the files that identify a project of an extension's type
+	ExtensionDetector struct {
+		Language   string   `json:"language"`
+		Files      []string `json:"files"`      // glob patterns relative to the project root, each must match a file
+		Contains   string   `json:"contains"`   // text that one of the matched files must contain, if set
+		Confidence int      `json:"confidence"` // how sure a match is, from 1 to 100
 	}
 
 	// ExtensionCommand represents a command defined by a project extension
@@ -14,16 +14,15 @@ package utils
 import (
 	"archive/tar"
 	"archive/zip"
-	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"path"
 	"path/filepath"
 	"runtime/debug"
+	"sort"
 	"strings"
 
 	"github.com/eclipse/codewind-installer/pkg/errors"
@@ -191,16 +190,38 @@ func DirIsEmpty(path string) (bool, error) {
 
 // ReplaceInFiles the placeholder string "[PROJ_NAME_PLACEHOLDER]" with a generated name based on the project directory
 func ReplaceInFiles(projectPath string, oldStr string, newStr string) error {
+	return ReplaceAllInFiles(projectPath, map[string]string{oldStr: newStr})
+}
 
-	oldBytes := []byte(oldStr)
-	newBytes := []byte(newStr)
+// ReplaceAllInFiles replaces every placeholder string with its value, in the contents and names of the files and directories in projectPath
+func ReplaceAllInFiles(projectPath string, replacements map[string]string) error {
+	placeholders := []string{}
+	for placeholder := range replacements {
+		placeholders = append(placeholders, placeholder)
+	}
+	// replace the longest placeholders first, so one that contains another is replaced whole
+	sort.Slice(placeholders, func(i, j int) bool {
+		if len(placeholders[i]) != len(placeholders[j]) {
+			return len(placeholders[i]) > len(placeholders[j])
+		}
+		return placeholders[i] < placeholders[j]
+	})
+	oldNew := []string{}
+	for _, placeholder := range placeholders {
+		oldNew = append(oldNew, placeholder, replacements[placeholder])
+	}
+	replacer := strings.NewReplacer(oldNew...)
 
 	pathsToRename := []string{}
 
 	lastError := error(nil)
 	filepath.Walk(projectPath, func(pathName string, info os.FileInfo, err error) error {
+		if err != nil {
+			lastError = err
+			return nil
+		}
 
-		if strings.Contains(path.Base(pathName), oldStr) {
+		if replacer.Replace(filepath.Base(pathName)) != filepath.Base(pathName) {
 			// Keep track of files we need to rename but don't rename
 			// them until the filepath.Walk is complete.
 			pathsToRename = append(pathsToRename, pathName)
@@ -215,17 +236,24 @@ func ReplaceInFiles(projectPath string, oldStr string, newStr string) error {
 			lastError = err
 			return nil
 		}
-		newContent := bytes.Replace(content, []byte(oldBytes), []byte(newBytes), -1)
-		if err = ioutil.WriteFile(pathName, newContent, info.Mode()); err != nil {
+		newContent := replacer.Replace(string(content))
+		if newContent == string(content) {
+			return nil
+		}
+		if err = ioutil.WriteFile(pathName, []byte(newContent), info.Mode()); err != nil {
 			lastError = err
 			return nil
 		}
 		return nil
 	})
 
-	for _, pathName := range pathsToRename {
-		newPath := strings.Replace(pathName, oldStr, newStr, -1)
-		os.Rename(pathName, newPath)
+	// rename the deepest paths first, so renaming a directory does not move the paths inside it
+	for i := len(pathsToRename) - 1; i >= 0; i-- {
+		pathName := pathsToRename[i]
+		newPath := filepath.Join(filepath.Dir(pathName), replacer.Replace(filepath.Base(pathName)))
+		if err := os.Rename(pathName, newPath); err != nil {
+			lastError = err
+		}
 	}
 
 	return lastError
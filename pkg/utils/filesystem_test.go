@@ -15,6 +15,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -131,3 +132,19 @@ func TestReplaceInFiles(t *testing.T) {
 		assert.Equal(t, wantFileContent, fileContent)
 	})
 }
+
+func TestReplaceAllInFiles(t *testing.T) {
+	t.Run("replaces placeholders in file contents and in nested file and directory names", func(t *testing.T) {
+		projectPath, _ := ioutil.TempDir("", "replace_test")
+		defer os.RemoveAll(projectPath)
+		os.MkdirAll(filepath.Join(projectPath, "src", "[[package]]", "[[name]]"), 0777)
+		ioutil.WriteFile(filepath.Join(projectPath, "src", "[[package]]", "[[name]]", "[[name]].java"), []byte("package [[package]]; class [[name]] {} // [[name]]X"), 0644)
+
+		err := ReplaceAllInFiles(projectPath, map[string]string{"[[package]]": "example", "[[name]]": "App", "[[name]]X": "Suffix"})
+		assert.Nil(t, err)
+
+		fileContent, err := ioutil.ReadFile(filepath.Join(projectPath, "src", "example", "App", "App.java"))
+		assert.Nil(t, err)
+		assert.Equal(t, "package example; class App {} // Suffix", string(fileContent))
+	})
+}
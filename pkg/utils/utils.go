@@ -14,6 +14,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -48,3 +49,31 @@ func PrettyPrintJSON(i interface{}) {
 func CreateTimestamp() int64 {
 	return time.Now().UnixNano() / int64(time.Millisecond)
 }
+
+// RunInParallel : Call run with every index from 0 to count-1, at most parallel at a time, returning once every call has.
+// Each index is given to exactly one call, so run can write to the index of a slice without locking.
+func RunInParallel(count int, parallel int, run func(index int)) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	if parallel > count {
+		parallel = count
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				run(index)
+			}
+		}()
+	}
+	for index := 0; index < count; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+}
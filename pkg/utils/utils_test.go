@@ -13,7 +13,11 @@ package utils
 
 import (
 	"log"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRemoveDuplicateEntries(t *testing.T) {
@@ -36,3 +40,40 @@ func TestRemoveDuplicateEntries(t *testing.T) {
 		log.Fatal("Test 3: Failed to identify empty array values")
 	}
 }
+
+func TestRunInParallel(t *testing.T) {
+	tests := map[string]struct {
+		count           int
+		parallel        int
+		wantMaxInFlight int
+	}{
+		"success case - no more than parallel at a time":  {count: 10, parallel: 3, wantMaxInFlight: 3},
+		"success case - fewer indexes than parallel":      {count: 2, parallel: 5, wantMaxInFlight: 2},
+		"success case - less than one runs one at a time": {count: 4, parallel: 0, wantMaxInFlight: 1},
+		"success case - nothing to run":                   {count: 0, parallel: 3, wantMaxInFlight: 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			inFlight, maxInFlight := 0, 0
+			calls := make([]int, test.count)
+			RunInParallel(test.count, test.parallel, func(index int) {
+				mutex.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mutex.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				calls[index]++
+				mutex.Lock()
+				inFlight--
+				mutex.Unlock()
+			})
+			assert.Equal(t, test.wantMaxInFlight, maxInFlight)
+			for index, count := range calls {
+				assert.Equal(t, 1, count, "index %v", index)
+			}
+		})
+	}
+}